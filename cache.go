@@ -0,0 +1,236 @@
+package jsonschema
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"unsafe"
+)
+
+// reflectCache stores the definitions generated with CacheSchemas. Each
+// Reflector creates its own, so copies of a Reflector never share one.
+type reflectCache struct {
+	owner *Reflector
+
+	mu          sync.Mutex
+	options     reflectOptions
+	definitions map[reflect.Type]*cachedDefinition
+}
+
+// cachedDefinition holds a clone of a type's definition and of every other
+// definition it references, along with the types they were generated from.
+type cachedDefinition struct {
+	definitions Definitions
+	types       map[string]reflect.Type
+}
+
+// schemaCache provides the Reflector's own cache, creating it if needed. A
+// cache found in the Reflector that belongs to another was copied along with
+// it, and is replaced.
+func (r *Reflector) schemaCache() *reflectCache {
+	for {
+		v := r.cache.Load()
+		if c, _ := v.(*reflectCache); c != nil && c.owner == r {
+			return c
+		}
+		c := &reflectCache{owner: r}
+		if r.cache.CompareAndSwap(v, c) {
+			return c
+		}
+	}
+}
+
+// definitionCache provides the cache to use for a reflection call along with
+// the options it was prepared for, dropping any definitions generated with
+// different options. No cache is used unless CacheSchemas is set.
+func (r *Reflector) definitionCache() (*reflectCache, reflectOptions) {
+	if !r.CacheSchemas {
+		return nil, reflectOptions{}
+	}
+	c := r.schemaCache()
+	opts := r.cacheOptions()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.options != opts {
+		c.options = opts
+		c.definitions = nil
+	}
+	return c, opts
+}
+
+// ClearCache removes all the definitions stored by the CacheSchemas option.
+func (r *Reflector) ClearCache() {
+	c, _ := r.cache.Load().(*reflectCache)
+	if c == nil || c.owner != r {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.definitions = nil
+}
+
+// cachedDefinition adds the type's cached definition, and those it depends
+// on, to the definitions, and provides a reference to it. Nothing is added
+// unless all the names would be given to the same types in this call.
+func (r *Reflector) cachedDefinition(definitions Definitions, t reflect.Type) *Schema {
+	if r.state == nil || r.state.cache == nil || r.DoNotReference {
+		return nil
+	}
+	c := r.state.cache
+	c.mu.Lock()
+	entry := c.definitions[t]
+	c.mu.Unlock()
+	if entry == nil {
+		return nil
+	}
+	for name, dt := range entry.types {
+		if r.definitionName(dt) != name {
+			return nil
+		}
+	}
+	for name, dt := range entry.types {
+		if _, ok := definitions[name]; ok {
+			continue
+		}
+		r.state.names[dt] = name
+		r.state.types[name] = dt
+		definitions[name] = entry.definitions[name].Clone()
+	}
+	return r.refDefinition(definitions, t)
+}
+
+// storeDefinitions adds the definitions generated in a reflection call that
+// found no problems to the cache, unless the options changed meanwhile.
+func (r *Reflector) storeDefinitions(definitions Definitions) {
+	if r.state == nil || r.state.cache == nil || r.DoNotReference {
+		return
+	}
+	if len(r.state.errs) > 0 || len(r.state.conflicts) > 0 {
+		return
+	}
+	entries := make(map[reflect.Type]*cachedDefinition)
+	for t, name := range r.state.names {
+		if entry := r.definitionClosure(definitions, name); entry != nil {
+			entries[t] = entry
+		}
+	}
+
+	c := r.state.cache
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.options != r.state.options {
+		return
+	}
+	if c.definitions == nil {
+		c.definitions = make(map[reflect.Type]*cachedDefinition)
+	}
+	for t, entry := range entries {
+		if _, ok := c.definitions[t]; !ok {
+			c.definitions[t] = entry
+		}
+	}
+}
+
+// definitionClosure clones the named definition and all the definitions it
+// references, directly or not. It provides nil if any of them is missing.
+func (r *Reflector) definitionClosure(definitions Definitions, name string) *cachedDefinition {
+	entry := &cachedDefinition{
+		definitions: Definitions{},
+		types:       make(map[string]reflect.Type),
+	}
+	pending := []string{name}
+	for len(pending) > 0 {
+		n := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if _, ok := entry.definitions[n]; ok {
+			continue
+		}
+		def, ok := definitions[n]
+		t, known := r.state.types[n]
+		if !ok || !known {
+			return nil
+		}
+		entry.definitions[n] = def.Clone()
+		entry.types[n] = t
+		def.walk("", func(_ string, s *Schema) bool {
+			if strings.HasPrefix(s.Ref, "#/$defs/") {
+				pending = append(pending, rootDefinitionName(strings.TrimPrefix(s.Ref, "#")))
+			}
+			return true
+		})
+	}
+	return entry
+}
+
+// reflectOptions identifies the options definitions were generated with.
+// Functions, maps, and slices are identified by their address, along with the
+// length of maps and slices.
+type reflectOptions struct {
+	baseSchemaID               ID
+	anonymous                  bool
+	assignAnchor               bool
+	allowAdditionalProperties  bool
+	requiredFromJSONSchemaTags bool
+	nullableFromType           bool
+	nullableAs                 NullableStyle
+	doNotReference             bool
+	expandedStruct             bool
+	embeddedAsAllOf            bool
+	inlineSingleUse            bool
+	asTuple                    unsafe.Pointer
+	fieldNameTag               string
+	jsonV2                     bool
+	validateTag                string
+	ignoredTypes               uintptr
+	ignoredTypesLen            int
+	contentSchemaTypes         uintptr
+	contentSchemaTypesLen      int
+	lookup                     unsafe.Pointer
+	mapper                     unsafe.Pointer
+	namer                      unsafe.Pointer
+	resolveNameConflict        unsafe.Pointer
+	keyNamer                   unsafe.Pointer
+	additionalFields           unsafe.Pointer
+	lookupComment              unsafe.Pointer
+	commentMap                 uintptr
+	commentMapLen              int
+}
+
+func (r *Reflector) cacheOptions() reflectOptions {
+	return reflectOptions{
+		baseSchemaID:               r.BaseSchemaID,
+		anonymous:                  r.Anonymous,
+		assignAnchor:               r.AssignAnchor,
+		allowAdditionalProperties:  r.AllowAdditionalProperties,
+		requiredFromJSONSchemaTags: r.RequiredFromJSONSchemaTags,
+		nullableFromType:           r.NullableFromType,
+		nullableAs:                 r.NullableAs,
+		doNotReference:             r.DoNotReference,
+		expandedStruct:             r.ExpandedStruct,
+		embeddedAsAllOf:            r.EmbeddedAsAllOf,
+		inlineSingleUse:            r.InlineSingleUse,
+		asTuple:                    funcPointer(r.AsTuple),
+		fieldNameTag:               r.FieldNameTag,
+		jsonV2:                     r.JSONv2,
+		validateTag:                r.ValidateTag,
+		ignoredTypes:               reflect.ValueOf(r.IgnoredTypes).Pointer(),
+		ignoredTypesLen:            len(r.IgnoredTypes),
+		contentSchemaTypes:         reflect.ValueOf(r.ContentSchemaTypes).Pointer(),
+		contentSchemaTypesLen:      len(r.ContentSchemaTypes),
+		lookup:                     funcPointer(r.Lookup),
+		mapper:                     funcPointer(r.Mapper),
+		namer:                      funcPointer(r.Namer),
+		resolveNameConflict:        funcPointer(r.ResolveNameConflict),
+		keyNamer:                   funcPointer(r.KeyNamer),
+		additionalFields:           funcPointer(r.AdditionalFields),
+		lookupComment:              funcPointer(r.LookupComment),
+		commentMap:                 reflect.ValueOf(r.CommentMap).Pointer(),
+		commentMapLen:              len(r.CommentMap),
+	}
+}
+
+// funcPointer provides the address of the function value, which, unlike its
+// code pointer, differs between closures of the same function.
+func funcPointer[F any](f F) unsafe.Pointer {
+	return *(*unsafe.Pointer)(unsafe.Pointer(&f))
+}
@@ -0,0 +1,181 @@
+package jsonschema
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"sort"
+	"strconv"
+)
+
+// Canonicalize provides a copy of the schema in a canonical form, so that
+// schemas that only differ in ways that do not affect validation will be
+// serialized identically:
+//
+//   - properties are sorted by name,
+//   - `required`, `enum`, and `dependentRequired` lists are sorted, and
+//     duplicate values removed,
+//   - numbers, including those inside values like `const` or `default`, use
+//     their shortest form, so `1.0` and `1e0` both become `1`,
+//   - empty properties are removed.
+//
+// The order of other lists, like `allOf` or `examples`, is preserved. Maps,
+// like `$defs` and extras, are always serialized with their keys sorted.
+func Canonicalize(s *Schema) *Schema {
+	c := s.clone()
+	c.walk("", func(_ string, x *Schema) bool {
+		x.canonicalize()
+		return true
+	})
+	return c
+}
+
+// Hash provides the hex encoded SHA-256 digest of the schema's canonical
+// form, which can be used to identify schemas by their content.
+func (t *Schema) Hash() (string, error) {
+	data, err := json.Marshal(Canonicalize(t))
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// canonicalize updates the schema's own keywords, but not those of its
+// sub-schemas. Values shared with other schemas are replaced, not modified.
+func (t *Schema) canonicalize() {
+	if t.boolean != nil {
+		return
+	}
+	if t.Properties != nil {
+		props := NewProperties()
+		keys := make([]string, 0, t.Properties.Len())
+		for pair := t.Properties.Oldest(); pair != nil; pair = pair.Next() {
+			keys = append(keys, pair.Key)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			p, _ := t.Properties.Get(k)
+			props.Set(k, p)
+		}
+		t.Properties = props
+		if len(keys) == 0 {
+			t.Properties = nil
+		}
+	}
+	t.setTypes(sortedUniqueStrings(t.typeList()))
+	t.Required = sortedUniqueStrings(t.Required)
+	if t.DependentRequired != nil {
+		dr := make(map[string][]string, len(t.DependentRequired))
+		for k, list := range t.DependentRequired {
+			dr[k] = sortedUniqueStrings(list)
+		}
+		t.DependentRequired = dr
+	}
+
+	t.canonicalizeValues()
+
+	t.MultipleOf = canonicalNumber(t.MultipleOf)
+	t.Maximum = canonicalNumber(t.Maximum)
+	t.ExclusiveMaximum = canonicalNumber(t.ExclusiveMaximum)
+	t.Minimum = canonicalNumber(t.Minimum)
+	t.ExclusiveMinimum = canonicalNumber(t.ExclusiveMinimum)
+}
+
+func (t *Schema) canonicalizeValues() {
+	if t.Enum != nil {
+		keys := make(map[string]any, len(t.Enum))
+		for _, v := range t.Enum {
+			v = canonicalValue(v)
+			keys[valueKey(v)] = v
+		}
+		enum := make([]any, 0, len(keys))
+		for _, k := range sortedKeys(keys) {
+			enum = append(enum, keys[k])
+		}
+		t.Enum = enum
+	}
+	t.Const = canonicalValue(t.Const)
+	t.Default = canonicalValue(t.Default)
+	if t.Examples != nil {
+		examples := make([]any, len(t.Examples))
+		for i, v := range t.Examples {
+			examples[i] = canonicalValue(v)
+		}
+		t.Examples = examples
+	}
+	if t.Extras != nil {
+		extras := make(map[string]any, len(t.Extras))
+		for k, v := range t.Extras {
+			extras[k] = canonicalValue(v)
+		}
+		t.Extras = extras
+	}
+}
+
+func sortedUniqueStrings(list []string) []string {
+	if len(list) == 0 {
+		return nil
+	}
+	var res []string
+	for _, s := range list {
+		res = appendUniqueString(res, s)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// canonicalValue converts the value into the generic structure it would be
+// decoded as from JSON, with all numbers in their canonical form.
+func canonicalValue(v any) any {
+	if v == nil {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var res any
+	if err := dec.Decode(&res); err != nil {
+		return v
+	}
+	return canonicalNumbers(res)
+}
+
+func canonicalNumbers(v any) any {
+	switch val := v.(type) {
+	case json.Number:
+		return canonicalNumber(val)
+	case []any:
+		for i, e := range val {
+			val[i] = canonicalNumbers(e)
+		}
+	case map[string]any:
+		for k, e := range val {
+			val[k] = canonicalNumbers(e)
+		}
+	}
+	return v
+}
+
+// canonicalNumber provides integers without fractions or exponents, and
+// other numbers in the shortest form that will be decoded to the same
+// float64 value.
+func canonicalNumber(n json.Number) json.Number {
+	if n == "" {
+		return n
+	}
+	r, ok := new(big.Rat).SetString(n.String())
+	if !ok {
+		return n
+	}
+	if r.IsInt() {
+		return json.Number(r.Num().String())
+	}
+	f, _ := r.Float64()
+	return json.Number(strconv.FormatFloat(f, 'g', -1, 64))
+}
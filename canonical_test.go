@@ -0,0 +1,71 @@
+package jsonschema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanonicalize(t *testing.T) {
+	src := `{
+		"$defs": {
+			"B": {"type": "integer", "enum": [3, 1.0, 2e0, 1]},
+			"A": {"const": {"n": 1.50}, "default": 10.0},
+			"C": {"type": ["null", "string", "null"]},
+			"D": {"type": ["string"]}
+		},
+		"type": "object",
+		"properties": {
+			"z": {"$ref": "#/$defs/B", "maximum": 1e2},
+			"a": {"$ref": "#/$defs/A", "minimum": 0.10, "examples": [2.0, 1]}
+		},
+		"required": ["z", "a", "z"],
+		"dependentRequired": {"a": ["z", "b"]}
+	}`
+	s := mergeTestSchema(t, src)
+	c := Canonicalize(s)
+	assert.JSONEq(t, `{
+		"$defs": {
+			"A": {"const": {"n": 1.5}, "default": 10},
+			"B": {"type": "integer", "enum": [1, 2, 3]},
+			"C": {"type": ["null", "string"]},
+			"D": {"type": "string"}
+		},
+		"type": "object",
+		"properties": {
+			"a": {"$ref": "#/$defs/A", "minimum": 0.1, "examples": [2, 1]},
+			"z": {"$ref": "#/$defs/B", "maximum": 100}
+		},
+		"required": ["a", "z"],
+		"dependentRequired": {"a": ["b", "z"]}
+	}`, mergeTestJSON(t, c))
+	assert.Equal(t, "a", c.Properties.Oldest().Key)
+	assert.JSONEq(t, src, mergeTestJSON(t, s), "original should not be modified")
+}
+
+func TestSchemaHash(t *testing.T) {
+	a := mergeTestSchema(t, `{"type":"object","properties":{"a":{"type":"string"},"b":{"minimum":1.0}},"required":["a","b"]}`)
+	b := mergeTestSchema(t, `{"required":["b","a"],"properties":{"b":{"minimum":1},"a":{"type":"string"}},"type":"object"}`)
+	b.Extras = map[string]any{"x-tag": []int{1}}
+	a.Extras = map[string]any{"x-tag": []float64{1.0}}
+
+	ha, err := a.Hash()
+	require.NoError(t, err)
+	hb, err := b.Hash()
+	require.NoError(t, err)
+	assert.Equal(t, ha, hb)
+	assert.Len(t, ha, 64)
+
+	b.Required = []string{"a"}
+	hb, err = b.Hash()
+	require.NoError(t, err)
+	assert.NotEqual(t, ha, hb)
+
+	r := &Reflector{}
+	h1, err := r.Reflect(&TestUser{}).Hash()
+	require.NoError(t, err)
+	h2, err := r.Reflect(&TestUser{}).Hash()
+	require.NoError(t, err)
+	assert.Equal(t, h1, h2)
+}
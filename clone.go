@@ -0,0 +1,132 @@
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+)
+
+// Clone provides a deep copy of the schema, including all of its sub-schemas,
+// properties, boolean schemas, and values like `enum`, `default`, or extras,
+// so that the copy can be modified without affecting the original or any
+// other schema it shares sub-schemas with, like TrueSchema and FalseSchema.
+func (t *Schema) Clone() *Schema {
+	c := t.clone()
+	c.walk("", func(_ string, s *Schema) bool {
+		s.copyValues()
+		return true
+	})
+	return c
+}
+
+// Equal determines if both schemas are semantically the same, by comparing
+// their canonical forms. Differences that do not affect validation, like the
+// order of properties or `required` names, the format of numbers, or the use
+// of an empty schema instead of `true`, are ignored.
+func (t *Schema) Equal(other *Schema) bool {
+	if t == nil || other == nil {
+		return t == other
+	}
+	a, err := json.Marshal(Canonicalize(t))
+	if err != nil {
+		return false
+	}
+	b, err := json.Marshal(Canonicalize(other))
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(a, b)
+}
+
+// copyValues replaces the schema's own values that are not schemas with
+// copies.
+func (t *Schema) copyValues() {
+	t.Types = copyValue(t.Types).([]string)
+	t.Enum = copyValue(t.Enum).([]any)
+	t.Const = copyValue(t.Const)
+	t.Default = copyValue(t.Default)
+	t.Examples = copyValue(t.Examples).([]any)
+	t.Extras = copyValue(t.Extras).(map[string]any)
+	t.Required = copyValue(t.Required).([]string)
+	t.DependentRequired = copyValue(t.DependentRequired).(map[string][]string)
+	t.MaxLength = copyUint(t.MaxLength)
+	t.MinLength = copyUint(t.MinLength)
+	t.MaxItems = copyUint(t.MaxItems)
+	t.MinItems = copyUint(t.MinItems)
+	t.MaxContains = copyUint(t.MaxContains)
+	t.MinContains = copyUint(t.MinContains)
+	t.MaxProperties = copyUint(t.MaxProperties)
+	t.MinProperties = copyUint(t.MinProperties)
+}
+
+func copyUint(v *uint64) *uint64 {
+	if v == nil {
+		return nil
+	}
+	c := *v
+	return &c
+}
+
+// copyValue provides a deep copy of the maps, slices, arrays, pointers and
+// exported struct fields contained in the value.
+func copyValue(v any) any {
+	if v == nil {
+		return nil
+	}
+	return copyReflectValue(reflect.ValueOf(v)).Interface()
+}
+
+func copyReflectValue(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type().Elem())
+		c.Elem().Set(copyReflectValue(v.Elem()))
+		return c
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type()).Elem()
+		c.Set(copyReflectValue(v.Elem()))
+		return c
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(copyReflectValue(v.Index(i)))
+		}
+		return c
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			c.SetMapIndex(iter.Key(), copyReflectValue(iter.Value()))
+		}
+		return c
+	case reflect.Array, reflect.Struct:
+		c := reflect.New(v.Type()).Elem()
+		c.Set(v)
+		if v.Kind() == reflect.Array {
+			for i := 0; i < v.Len(); i++ {
+				c.Index(i).Set(copyReflectValue(v.Index(i)))
+			}
+			return c
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if c.Field(i).CanSet() {
+				c.Field(i).Set(copyReflectValue(v.Field(i)))
+			}
+		}
+		return c
+	default:
+		return v
+	}
+}
@@ -0,0 +1,50 @@
+package jsonschema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemaClone(t *testing.T) {
+	r := &Reflector{}
+	s := r.Reflect(&TestUser{})
+	s.Extras = map[string]any{"x-tags": []any{"a", map[string]any{"b": 1}}}
+	s.Enum = []any{[]string{"x"}}
+	original := mergeTestJSON(t, s)
+
+	c := s.Clone()
+	assert.Equal(t, original, mergeTestJSON(t, c))
+	assert.True(t, c.Equal(s))
+
+	user := c.Definitions["TestUser"]
+	require.NotNil(t, user)
+	assert.NotSame(t, s.Definitions["TestUser"], user)
+	assert.NotSame(t, FalseSchema, user.AdditionalProperties)
+	user.AdditionalProperties.boolean = nil
+	user.AdditionalProperties.Type = "string"
+	name, _ := user.Properties.Get("name")
+	*name.MaxLength = 1
+	user.Properties.Set("extra", &Schema{Type: "string"})
+	user.Required[0] = "changed"
+	c.Extras["x-tags"].([]any)[1].(map[string]any)["b"] = 2
+	c.Enum[0].([]string)[0] = "y"
+
+	assert.Equal(t, original, mergeTestJSON(t, s))
+	assert.False(t, FalseSchema.Equal(TrueSchema))
+	assert.False(t, c.Equal(s))
+}
+
+func TestSchemaEqual(t *testing.T) {
+	a := mergeTestSchema(t, `{"properties":{"a":{},"b":{"maximum":1.0}},"required":["a","b"]}`)
+	b := mergeTestSchema(t, `{"properties":{"b":{"maximum":1},"a":true},"required":["b","a"]}`)
+	assert.True(t, a.Equal(b))
+	assert.True(t, b.Equal(a))
+
+	b.Required = nil
+	assert.False(t, a.Equal(b))
+	assert.False(t, a.Equal(nil))
+	assert.True(t, (*Schema)(nil).Equal(nil))
+	assert.True(t, TrueSchema.Equal(&Schema{}))
+}
@@ -0,0 +1,630 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Compatibility contains the list of changes found when comparing two
+// versions of a schema, as provided by Compare.
+//
+// Changes are classified from the point of view of the data: a change
+// "breaks writers" when data produced according to the old schema may no
+// longer be valid according to the new one, because the new schema is more
+// restrictive. A change "breaks readers" when data produced according to the
+// new schema may be rejected by readers still using the old schema, because
+// the new schema is less restrictive. Annotation changes, like descriptions,
+// break neither.
+type Compatibility struct {
+	Changes []*CompatibilityChange
+}
+
+// CompatibilityChange describes a single difference between two schemas.
+type CompatibilityChange struct {
+	// Path is the JSON Pointer to the schema containing the keyword,
+	// relative to the root, with references to definitions followed as
+	// though they were defined inline.
+	Path string
+	// Keyword is the name of the JSON Schema keyword that changed.
+	Keyword string
+	// Description explains the change in a few words.
+	Description string
+	// Old and New contain the relevant values from each schema, if any.
+	Old any
+	New any
+	// BreaksWriters is true if data valid for the old schema may not be
+	// valid for the new one.
+	BreaksWriters bool
+	// BreaksReaders is true if data valid for the new schema may not be
+	// valid for the old one.
+	BreaksReaders bool
+}
+
+// Compare checks two versions of a schema and provides the list of changes
+// that affect compatibility between them, following any references to the
+// root definitions of each schema.
+//
+// Combinations of schemas that are too complex to compare, like changes
+// inside `not` or `if`, are reported as breaking both readers and writers.
+func Compare(oldSchema, newSchema *Schema) *Compatibility {
+	c := &comparer{
+		oldRoot: oldSchema,
+		newRoot: newSchema,
+		visited: make(map[[2]*Schema]bool),
+	}
+	c.compare("", oldSchema, newSchema)
+	return &Compatibility{Changes: c.changes}
+}
+
+// BackwardCompatible returns true if the new schema accepts all the data
+// accepted by the old schema, so no change breaks writers.
+func (c *Compatibility) BackwardCompatible() bool {
+	for _, ch := range c.Changes {
+		if ch.BreaksWriters {
+			return false
+		}
+	}
+	return true
+}
+
+// ForwardCompatible returns true if the old schema accepts all the data
+// accepted by the new schema, so no change breaks readers.
+func (c *Compatibility) ForwardCompatible() bool {
+	for _, ch := range c.Changes {
+		if ch.BreaksReaders {
+			return false
+		}
+	}
+	return true
+}
+
+// Breaking provides the list of changes that break either readers or
+// writers.
+func (c *Compatibility) Breaking() []*CompatibilityChange {
+	var list []*CompatibilityChange
+	for _, ch := range c.Changes {
+		if ch.Breaking() {
+			list = append(list, ch)
+		}
+	}
+	return list
+}
+
+// Breaking returns true if the change breaks readers or writers.
+func (ch *CompatibilityChange) Breaking() bool {
+	return ch.BreaksReaders || ch.BreaksWriters
+}
+
+// String provides a human readable version of the change.
+func (ch *CompatibilityChange) String() string {
+	var class string
+	switch {
+	case ch.BreaksReaders && ch.BreaksWriters:
+		class = "breaks readers and writers"
+	case ch.BreaksWriters:
+		class = "breaks writers"
+	case ch.BreaksReaders:
+		class = "breaks readers"
+	default:
+		class = "non-breaking"
+	}
+	loc := ch.Path
+	if ch.Keyword != "" {
+		loc += "/" + ch.Keyword
+	}
+	if loc == "" {
+		loc = "/"
+	}
+	return fmt.Sprintf("%s: %s (%s)", loc, ch.Description, class)
+}
+
+type comparer struct {
+	oldRoot *Schema
+	newRoot *Schema
+	visited map[[2]*Schema]bool
+	changes []*CompatibilityChange
+}
+
+func (c *comparer) add(path, keyword, desc string, o, n any, writers, readers bool) {
+	c.changes = append(c.changes, &CompatibilityChange{
+		Path:          path,
+		Keyword:       keyword,
+		Description:   desc,
+		Old:           o,
+		New:           n,
+		BreaksWriters: writers,
+		BreaksReaders: readers,
+	})
+}
+
+// tightened records a change that makes the new schema more restrictive.
+func (c *comparer) tightened(path, keyword, desc string, o, n any) {
+	c.add(path, keyword, desc, o, n, true, false)
+}
+
+// loosened records a change that makes the new schema less restrictive.
+func (c *comparer) loosened(path, keyword, desc string, o, n any) {
+	c.add(path, keyword, desc, o, n, false, true)
+}
+
+// changed records an incompatible change in both directions.
+func (c *comparer) changed(path, keyword, desc string, o, n any) {
+	c.add(path, keyword, desc, o, n, true, true)
+}
+
+func (c *comparer) compare(path string, o, n *Schema) {
+	ro := resolveDefinitionRef(c.oldRoot, o)
+	rn := resolveDefinitionRef(c.newRoot, n)
+	if ro != o || rn != n {
+		// definitions are only compared once, avoiding infinite recursion
+		key := [2]*Schema{ro, rn}
+		if c.visited[key] {
+			return
+		}
+		c.visited[key] = true
+		o, n = ro, rn
+	}
+
+	ot, nt := o == nil || isTrueSchema(o), n == nil || isTrueSchema(n)
+	if ot && nt {
+		return
+	}
+	if ot {
+		o = &Schema{}
+	}
+	if nt {
+		n = &Schema{}
+	}
+	of, nf := isFalseSchema(o), isFalseSchema(n)
+	switch {
+	case of && nf:
+		return
+	case nf:
+		c.tightened(path, "", "schema no longer allows any value", o, n)
+		return
+	case of:
+		c.loosened(path, "", "schema now allows values", o, n)
+		return
+	}
+
+	if o.Ref != n.Ref {
+		c.changed(path, "$ref", "reference changed", o.Ref, n.Ref)
+	}
+	c.compareType(path, o, n)
+	c.compareValues(path, o, n)
+	c.compareBounds(path, o, n)
+	c.compareStrings(path, o, n)
+	c.compareObjects(path, o, n)
+	c.compareArrays(path, o, n)
+	c.compareLogic(path, o, n)
+	c.compareAnnotations(path, o, n)
+}
+
+func (c *comparer) compareType(path string, o, n *Schema) {
+	ot, nt := o.typeList(), n.typeList()
+	// determine if each schema allows all the types of the other
+	oldAllowed, newAllowed := allowsTypes(n, ot), allowsTypes(o, nt)
+	switch {
+	case oldAllowed && newAllowed:
+		return
+	case len(ot) == 0:
+		c.tightened(path, "type", "type added", o.typeValue(), n.typeValue())
+	case len(nt) == 0:
+		c.loosened(path, "type", "type removed", o.typeValue(), n.typeValue())
+	case oldAllowed:
+		c.loosened(path, "type", "type widened", o.typeValue(), n.typeValue())
+	case newAllowed:
+		c.tightened(path, "type", "type narrowed", o.typeValue(), n.typeValue())
+	default:
+		c.changed(path, "type", "type changed", o.typeValue(), n.typeValue())
+	}
+}
+
+// allowsTypes determines if the schema allows values of all the types
+// provided.
+func allowsTypes(s *Schema, types []string) bool {
+	for _, ty := range types {
+		if !s.allowsType(ty) {
+			return false
+		}
+	}
+	return len(types) > 0 || len(s.typeList()) == 0
+}
+
+func (c *comparer) compareValues(path string, o, n *Schema) {
+	switch {
+	case o.Enum == nil && n.Enum != nil:
+		c.tightened(path, "enum", "enum added", o.Enum, n.Enum)
+	case o.Enum != nil && n.Enum == nil:
+		c.loosened(path, "enum", "enum removed", o.Enum, n.Enum)
+	case o.Enum != nil:
+		oe, ne := valueSet(o.Enum), valueSet(n.Enum)
+		for _, v := range o.Enum {
+			if !ne[valueKey(v)] {
+				c.tightened(path, "enum", "enum value removed", v, nil)
+			}
+		}
+		for _, v := range n.Enum {
+			if !oe[valueKey(v)] {
+				c.loosened(path, "enum", "enum value added", nil, v)
+			}
+		}
+	}
+
+	switch {
+	case o.Const == nil && n.Const != nil:
+		c.tightened(path, "const", "const added", o.Const, n.Const)
+	case o.Const != nil && n.Const == nil:
+		c.loosened(path, "const", "const removed", o.Const, n.Const)
+	case valueKey(o.Const) != valueKey(n.Const):
+		c.changed(path, "const", "const changed", o.Const, n.Const)
+	}
+}
+
+func (c *comparer) compareBounds(path string, o, n *Schema) {
+	c.compareBound(path, "minimum", o.Minimum, n.Minimum, true)
+	c.compareBound(path, "exclusiveMinimum", o.ExclusiveMinimum, n.ExclusiveMinimum, true)
+	c.compareBound(path, "maximum", o.Maximum, n.Maximum, false)
+	c.compareBound(path, "exclusiveMaximum", o.ExclusiveMaximum, n.ExclusiveMaximum, false)
+	c.compareBound(path, "minLength", uintNumber(o.MinLength), uintNumber(n.MinLength), true)
+	c.compareBound(path, "maxLength", uintNumber(o.MaxLength), uintNumber(n.MaxLength), false)
+	c.compareBound(path, "minItems", uintNumber(o.MinItems), uintNumber(n.MinItems), true)
+	c.compareBound(path, "maxItems", uintNumber(o.MaxItems), uintNumber(n.MaxItems), false)
+	c.compareBound(path, "minContains", uintNumber(o.MinContains), uintNumber(n.MinContains), true)
+	c.compareBound(path, "maxContains", uintNumber(o.MaxContains), uintNumber(n.MaxContains), false)
+	c.compareBound(path, "minProperties", uintNumber(o.MinProperties), uintNumber(n.MinProperties), true)
+	c.compareBound(path, "maxProperties", uintNumber(o.MaxProperties), uintNumber(n.MaxProperties), false)
+	c.compareConstraint(path, "multipleOf", o.MultipleOf.String(), n.MultipleOf.String())
+}
+
+// compareBound checks the change in a numeric lower or upper bound.
+func (c *comparer) compareBound(path, keyword string, o, n json.Number, lower bool) {
+	switch {
+	case o == n:
+		return
+	case o == "":
+		c.tightened(path, keyword, keyword+" added", o, n)
+		return
+	case n == "":
+		c.loosened(path, keyword, keyword+" removed", o, n)
+		return
+	}
+	or, ok1 := new(big.Rat).SetString(o.String())
+	nr, ok2 := new(big.Rat).SetString(n.String())
+	if !ok1 || !ok2 {
+		c.changed(path, keyword, keyword+" changed", o, n)
+		return
+	}
+	cmp := nr.Cmp(or)
+	switch {
+	case cmp == 0:
+		return
+	case (cmp > 0) == lower:
+		c.tightened(path, keyword, keyword+directionName(cmp), o, n)
+	default:
+		c.loosened(path, keyword, keyword+directionName(cmp), o, n)
+	}
+}
+
+func directionName(cmp int) string {
+	if cmp > 0 {
+		return " increased"
+	}
+	return " decreased"
+}
+
+// compareConstraint checks keywords that restrict values when present, and
+// for which any modification is considered incompatible.
+func (c *comparer) compareConstraint(path, keyword, o, n string) {
+	switch {
+	case o == n:
+		return
+	case o == "":
+		c.tightened(path, keyword, keyword+" added", o, n)
+	case n == "":
+		c.loosened(path, keyword, keyword+" removed", o, n)
+	default:
+		c.changed(path, keyword, keyword+" changed", o, n)
+	}
+}
+
+func (c *comparer) compareStrings(path string, o, n *Schema) {
+	c.compareConstraint(path, "pattern", o.Pattern, n.Pattern)
+	c.compareConstraint(path, "format", o.Format, n.Format)
+	if o.ContentEncoding != n.ContentEncoding {
+		c.changed(path, "contentEncoding", "contentEncoding changed", o.ContentEncoding, n.ContentEncoding)
+	}
+	if o.ContentMediaType != n.ContentMediaType {
+		c.changed(path, "contentMediaType", "contentMediaType changed", o.ContentMediaType, n.ContentMediaType)
+	}
+}
+
+func (c *comparer) compareObjects(path string, o, n *Schema) {
+	oreq, nreq := stringSet(o.Required), stringSet(n.Required)
+	for _, r := range n.Required {
+		if !oreq[r] {
+			c.tightened(path, "required", fmt.Sprintf("property %q now required", r), nil, r)
+		}
+	}
+	for _, r := range o.Required {
+		if !nreq[r] {
+			c.loosened(path, "required", fmt.Sprintf("property %q no longer required", r), r, nil)
+		}
+	}
+
+	for _, k := range sortedKeys(mergeKeys(o.DependentRequired, n.DependentRequired)) {
+		oreq, nreq := stringSet(o.DependentRequired[k]), stringSet(n.DependentRequired[k])
+		for _, r := range n.DependentRequired[k] {
+			if !oreq[r] {
+				c.tightened(path, "dependentRequired", fmt.Sprintf("property %q now required with %q", r, k), nil, r)
+			}
+		}
+		for _, r := range o.DependentRequired[k] {
+			if !nreq[r] {
+				c.loosened(path, "dependentRequired", fmt.Sprintf("property %q no longer required with %q", r, k), r, nil)
+			}
+		}
+	}
+
+	c.compareProperties(path, o, n)
+
+	for _, k := range sortedKeys(mergeKeys(o.PatternProperties, n.PatternProperties)) {
+		c.compareMember(path+"/patternProperties/"+escapePointer(k), "patternProperties", o.PatternProperties[k], n.PatternProperties[k])
+	}
+	for _, k := range sortedKeys(mergeKeys(o.DependentSchemas, n.DependentSchemas)) {
+		c.compareMember(path+"/dependentSchemas/"+escapePointer(k), "dependentSchemas", o.DependentSchemas[k], n.DependentSchemas[k])
+	}
+	c.compare(path+"/additionalProperties", o.AdditionalProperties, n.AdditionalProperties)
+	c.compare(path+"/propertyNames", o.PropertyNames, n.PropertyNames)
+	c.compare(path+"/unevaluatedProperties", o.UnevaluatedProperties, n.UnevaluatedProperties)
+}
+
+// compareProperties checks each of the properties defined in either schema.
+// Properties only defined in one of them are compared with the other's
+// additional properties schema.
+func (c *comparer) compareProperties(path string, o, n *Schema) {
+	if o.Properties != nil {
+		for pair := o.Properties.Oldest(); pair != nil; pair = pair.Next() {
+			p := path + "/properties/" + escapePointer(pair.Key)
+			if n.Properties != nil {
+				if np, ok := n.Properties.Get(pair.Key); ok {
+					c.compare(p, pair.Value, np)
+					continue
+				}
+			}
+			switch {
+			case isFalseSchema(n.AdditionalProperties):
+				c.tightened(path, "properties", fmt.Sprintf("property %q removed and additional properties not allowed", pair.Key), pair.Key, nil)
+			case n.AdditionalProperties == nil || isTrueSchema(n.AdditionalProperties):
+				c.loosened(path, "properties", fmt.Sprintf("property %q removed", pair.Key), pair.Key, nil)
+			default:
+				c.compare(p, pair.Value, n.AdditionalProperties)
+			}
+		}
+	}
+	if n.Properties != nil {
+		for pair := n.Properties.Oldest(); pair != nil; pair = pair.Next() {
+			if o.Properties != nil {
+				if _, ok := o.Properties.Get(pair.Key); ok {
+					continue
+				}
+			}
+			switch {
+			case isFalseSchema(o.AdditionalProperties):
+				c.loosened(path, "properties", fmt.Sprintf("property %q added and additional properties were not allowed", pair.Key), nil, pair.Key)
+			case o.AdditionalProperties == nil || isTrueSchema(o.AdditionalProperties):
+				c.compare(path+"/properties/"+escapePointer(pair.Key), nil, pair.Value)
+			default:
+				c.compare(path+"/properties/"+escapePointer(pair.Key), o.AdditionalProperties, pair.Value)
+			}
+		}
+	}
+}
+
+// compareMember checks sub-schemas that restrict values further when
+// present, like pattern properties or dependent schemas.
+func (c *comparer) compareMember(path, keyword string, o, n *Schema) {
+	switch {
+	case o == nil:
+		c.tightened(path, keyword, keyword+" added", nil, n)
+	case n == nil:
+		c.loosened(path, keyword, keyword+" removed", o, nil)
+	default:
+		c.compare(path, o, n)
+	}
+}
+
+func (c *comparer) compareArrays(path string, o, n *Schema) {
+	if !o.UniqueItems && n.UniqueItems {
+		c.tightened(path, "uniqueItems", "unique items now required", false, true)
+	} else if o.UniqueItems && !n.UniqueItems {
+		c.loosened(path, "uniqueItems", "unique items no longer required", true, false)
+	}
+	for i := 0; i < len(o.PrefixItems) || i < len(n.PrefixItems); i++ {
+		op, np := o.Items, n.Items
+		if i < len(o.PrefixItems) {
+			op = o.PrefixItems[i]
+		}
+		if i < len(n.PrefixItems) {
+			np = n.PrefixItems[i]
+		}
+		c.compare(path+"/prefixItems/"+strconv.Itoa(i), op, np)
+	}
+	c.compare(path+"/items", o.Items, n.Items)
+	if o.Contains != nil || n.Contains != nil {
+		c.compareMember(path+"/contains", "contains", o.Contains, n.Contains)
+	}
+}
+
+func (c *comparer) compareLogic(path string, o, n *Schema) {
+	c.compareList(path, "allOf", o.AllOf, n.AllOf, true)
+	c.compareList(path, "anyOf", o.AnyOf, n.AnyOf, false)
+	c.compareList(path, "oneOf", o.OneOf, n.OneOf, false)
+	for _, kw := range []struct {
+		name string
+		o, n *Schema
+	}{
+		{"not", o.Not, n.Not},
+		{"if", o.If, n.If},
+		{"then", o.Then, n.Then},
+		{"else", o.Else, n.Else},
+	} {
+		if !sameJSON(kw.o, kw.n) {
+			c.changed(path, kw.name, kw.name+" changed", kw.o, kw.n)
+		}
+	}
+}
+
+// compareList checks the sub-schemas of allOf, anyOf, and oneOf by position.
+// Adding sub-schemas to allOf restricts the schema, while adding them to
+// anyOf or oneOf broadens it.
+func (c *comparer) compareList(path, keyword string, o, n []*Schema, all bool) {
+	for i := 0; i < len(o) || i < len(n); i++ {
+		p := path + "/" + keyword + "/" + strconv.Itoa(i)
+		switch {
+		case i >= len(o):
+			if all {
+				c.tightened(path, keyword, keyword+" sub-schema added", nil, n[i])
+			} else {
+				c.loosened(path, keyword, keyword+" sub-schema added", nil, n[i])
+			}
+		case i >= len(n):
+			if all {
+				c.loosened(path, keyword, keyword+" sub-schema removed", o[i], nil)
+			} else {
+				c.tightened(path, keyword, keyword+" sub-schema removed", o[i], nil)
+			}
+		default:
+			c.compare(p, o[i], n[i])
+		}
+	}
+}
+
+func (c *comparer) compareAnnotations(path string, o, n *Schema) {
+	for _, kw := range []struct {
+		name string
+		o, n any
+	}{
+		{"title", o.Title, n.Title},
+		{"description", o.Description, n.Description},
+		{"default", o.Default, n.Default},
+		{"examples", o.Examples, n.Examples},
+		{"deprecated", o.Deprecated, n.Deprecated},
+		{"readOnly", o.ReadOnly, n.ReadOnly},
+		{"writeOnly", o.WriteOnly, n.WriteOnly},
+		{"$comment", o.Comments, n.Comments},
+	} {
+		if !reflect.DeepEqual(kw.o, kw.n) {
+			c.add(path, kw.name, kw.name+" changed", kw.o, kw.n, false, false)
+		}
+	}
+	if !sameJSON(o.ContentSchema, n.ContentSchema) {
+		c.add(path, "contentSchema", "contentSchema changed", o.ContentSchema, n.ContentSchema, false, false)
+	}
+}
+
+// resolveDefinitionRef follows references to the root's definitions, as long
+// as the referencing schema does not contain any other constraints.
+func resolveDefinitionRef(root, s *Schema) *Schema {
+	for i := 0; s != nil && root != nil && i < len(root.Definitions); i++ {
+		if !strings.HasPrefix(s.Ref, defsRefPrefix) {
+			break
+		}
+		name := strings.TrimPrefix(s.Ref, defsRefPrefix)
+		if strings.Contains(name, "/") {
+			break
+		}
+		def, ok := root.Definitions[unescapePointer(name)]
+		if !ok || !isPlainRef(s) {
+			break
+		}
+		s = def
+	}
+	return s
+}
+
+// isPlainRef returns true if the schema only contains a reference,
+// annotations, and document keywords like `$defs`.
+func isPlainRef(s *Schema) bool {
+	c := &Schema{
+		Version:     s.Version,
+		ID:          s.ID,
+		Anchor:      s.Anchor,
+		Definitions: s.Definitions,
+		Ref:         s.Ref,
+		Title:       s.Title,
+		Description: s.Description,
+		Comments:    s.Comments,
+		Default:     s.Default,
+		Examples:    s.Examples,
+		Deprecated:  s.Deprecated,
+		ReadOnly:    s.ReadOnly,
+		WriteOnly:   s.WriteOnly,
+	}
+	return reflect.DeepEqual(c, s)
+}
+
+func isTrueSchema(s *Schema) bool {
+	if s.boolean != nil {
+		return *s.boolean
+	}
+	return reflect.DeepEqual(s, &Schema{})
+}
+
+func isFalseSchema(s *Schema) bool {
+	return s != nil && s.boolean != nil && !*s.boolean
+}
+
+func uintNumber(v *uint64) json.Number {
+	if v == nil {
+		return ""
+	}
+	return json.Number(strconv.FormatUint(*v, 10))
+}
+
+// valueKey provides a string that can be used to compare JSON values.
+func valueKey(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%#v", v)
+	}
+	return string(data)
+}
+
+func valueSet(list []any) map[string]bool {
+	set := make(map[string]bool, len(list))
+	for _, v := range list {
+		set[valueKey(v)] = true
+	}
+	return set
+}
+
+func stringSet(list []string) map[string]bool {
+	set := make(map[string]bool, len(list))
+	for _, v := range list {
+		set[v] = true
+	}
+	return set
+}
+
+func mergeKeys[M ~map[string]V, V any](a, b M) M {
+	m := make(M, len(a)+len(b))
+	for k, v := range a {
+		m[k] = v
+	}
+	for k, v := range b {
+		m[k] = v
+	}
+	return m
+}
+
+func sameJSON(a, b *Schema) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return valueKey(a) == valueKey(b)
+}
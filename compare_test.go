@@ -0,0 +1,232 @@
+package jsonschema
+
+import (
+	"testing"
+
+	orderedmap "github.com/pb33f/ordered-map/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type CompareAddressV1 struct {
+	Street string `json:"street" jsonschema:"maxLength=100"`
+	Zip    string `json:"zip,omitempty"`
+}
+
+type CompareUserV1 struct {
+	Name    string           `json:"name" jsonschema:"maxLength=20"`
+	Kind    string           `json:"kind" jsonschema:"enum=person,enum=company"`
+	Age     int              `json:"age,omitempty" jsonschema:"minimum=0"`
+	Email   string           `json:"email,omitempty"`
+	Address CompareAddressV1 `json:"address"`
+	Friends []*CompareUserV1 `json:"friends,omitempty"`
+}
+
+type CompareAddressV2 struct {
+	Street string `json:"street" jsonschema:"maxLength=50"`
+	Zip    string `json:"zip"`
+}
+
+type CompareUserV2 struct {
+	Name    string           `json:"name" jsonschema:"maxLength=20,description=Full name"`
+	Kind    string           `json:"kind" jsonschema:"enum=person,enum=company,enum=government"`
+	Age     float64          `json:"age,omitempty" jsonschema:"minimum=18"`
+	Phone   string           `json:"phone,omitempty"`
+	Address CompareAddressV2 `json:"address"`
+	Friends []*CompareUserV2 `json:"friends,omitempty"`
+}
+
+func compareChangeStrings(c *Compatibility) []string {
+	list := make([]string, len(c.Changes))
+	for i, ch := range c.Changes {
+		list[i] = ch.String()
+	}
+	return list
+}
+
+func TestCompare(t *testing.T) {
+	r := &Reflector{}
+	c := Compare(r.Reflect(&CompareUserV1{}), r.Reflect(&CompareUserV2{}))
+	assert.Equal(t, []string{
+		"/properties/name/description: description changed (non-breaking)",
+		"/properties/kind/enum: enum value added (breaks readers)",
+		"/properties/age/type: type widened (breaks readers)",
+		"/properties/age/minimum: minimum increased (breaks writers)",
+		`/properties: property "email" removed and additional properties not allowed (breaks writers)`,
+		`/properties/address/required: property "zip" now required (breaks writers)`,
+		"/properties/address/properties/street/maxLength: maxLength decreased (breaks writers)",
+		`/properties: property "phone" added and additional properties were not allowed (breaks readers)`,
+	}, compareChangeStrings(c))
+	assert.False(t, c.BackwardCompatible())
+	assert.False(t, c.ForwardCompatible())
+	assert.Len(t, c.Breaking(), 7)
+
+	c = Compare(r.Reflect(&CompareUserV1{}), r.Reflect(&CompareUserV1{}))
+	assert.Empty(t, c.Changes)
+	assert.True(t, c.BackwardCompatible())
+	assert.True(t, c.ForwardCompatible())
+}
+
+func TestCompareKeywords(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new *Schema
+		expected []string
+	}{
+		{
+			"type narrowed",
+			&Schema{Type: "number"},
+			&Schema{Type: "integer"},
+			[]string{"/type: type narrowed (breaks writers)"},
+		},
+		{
+			"type widened to null",
+			&Schema{Type: "string"},
+			&Schema{Types: []string{"string", "null"}},
+			[]string{"/type: type widened (breaks readers)"},
+		},
+		{
+			"type list narrowed",
+			&Schema{Types: []string{"number", "null"}},
+			&Schema{Types: []string{"null", "integer"}},
+			[]string{"/type: type narrowed (breaks writers)"},
+		},
+		{
+			"type list changed",
+			&Schema{Types: []string{"string", "null"}},
+			&Schema{Types: []string{"string", "boolean"}},
+			[]string{"/type: type changed (breaks readers and writers)"},
+		},
+		{
+			"type changed",
+			&Schema{Type: "string"},
+			&Schema{Type: "boolean"},
+			[]string{"/type: type changed (breaks readers and writers)"},
+		},
+		{
+			"enum removed value",
+			&Schema{Enum: []any{"a", "b"}},
+			&Schema{Enum: []any{"a"}},
+			[]string{"/enum: enum value removed (breaks writers)"},
+		},
+		{
+			"enum dropped",
+			&Schema{Enum: []any{"a", "b"}},
+			&Schema{},
+			[]string{"/enum: enum removed (breaks readers)"},
+		},
+		{
+			"const changed",
+			&Schema{Const: "a"},
+			&Schema{Const: "b"},
+			[]string{"/const: const changed (breaks readers and writers)"},
+		},
+		{
+			"bounds",
+			&Schema{MaxLength: ptrUint(10), Maximum: "5", MinItems: ptrUint(1)},
+			&Schema{MaxLength: ptrUint(20), ExclusiveMaximum: "5", MinItems: ptrUint(2)},
+			[]string{
+				"/maximum: maximum removed (breaks readers)",
+				"/exclusiveMaximum: exclusiveMaximum added (breaks writers)",
+				"/maxLength: maxLength increased (breaks readers)",
+				"/minItems: minItems increased (breaks writers)",
+			},
+		},
+		{
+			"pattern",
+			&Schema{Pattern: "^a"},
+			&Schema{Pattern: "^b", Format: "email"},
+			[]string{
+				"/pattern: pattern changed (breaks readers and writers)",
+				"/format: format added (breaks writers)",
+			},
+		},
+		{
+			"additional properties",
+			&Schema{Type: "object"},
+			&Schema{Type: "object", AdditionalProperties: FalseSchema},
+			[]string{"/additionalProperties: schema no longer allows any value (breaks writers)"},
+		},
+		{
+			"property added to open object",
+			&Schema{Type: "object"},
+			&Schema{Type: "object", Properties: propertiesOf("a", &Schema{Type: "string"})},
+			[]string{"/properties/a/type: type added (breaks writers)"},
+		},
+		{
+			"property removed from open object",
+			&Schema{Type: "object", Properties: propertiesOf("a", &Schema{Type: "string"})},
+			&Schema{Type: "object"},
+			[]string{`/properties: property "a" removed (breaks readers)`},
+		},
+		{
+			"tuple items",
+			&Schema{PrefixItems: []*Schema{{Type: "string"}}, Items: FalseSchema},
+			&Schema{PrefixItems: []*Schema{{Type: "string"}, {Type: "integer"}}, Items: FalseSchema},
+			[]string{"/prefixItems/1: schema now allows values (breaks readers)"},
+		},
+		{
+			"anyOf and allOf",
+			&Schema{AnyOf: []*Schema{{Type: "string"}}, AllOf: []*Schema{{Type: "string"}}},
+			&Schema{AnyOf: []*Schema{{Type: "string"}, {Type: "null"}}, AllOf: []*Schema{{Type: "string"}, {MinLength: ptrUint(1)}}},
+			[]string{
+				"/allOf: allOf sub-schema added (breaks writers)",
+				"/anyOf: anyOf sub-schema added (breaks readers)",
+			},
+		},
+		{
+			"not",
+			&Schema{Not: &Schema{Type: "string"}},
+			&Schema{Not: &Schema{Type: "integer"}},
+			[]string{"/not: not changed (breaks readers and writers)"},
+		},
+		{
+			"dependent required",
+			&Schema{DependentRequired: map[string][]string{"card": {"cvv"}}},
+			&Schema{DependentRequired: map[string][]string{"card": {"expiry"}}},
+			[]string{
+				`/dependentRequired: property "expiry" now required with "card" (breaks writers)`,
+				`/dependentRequired: property "cvv" no longer required with "card" (breaks readers)`,
+			},
+		},
+		{
+			"unique items",
+			&Schema{Type: "array"},
+			&Schema{Type: "array", UniqueItems: true},
+			[]string{"/uniqueItems: unique items now required (breaks writers)"},
+		},
+		{
+			"boolean schemas",
+			FalseSchema,
+			TrueSchema,
+			[]string{"/: schema now allows values (breaks readers)"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, compareChangeStrings(Compare(tt.old, tt.new)))
+		})
+	}
+}
+
+func TestCompareUnresolvedRefs(t *testing.T) {
+	c := Compare(
+		&Schema{Ref: "https://example.com/schemas/a"},
+		&Schema{Ref: "https://example.com/schemas/b"},
+	)
+	require.Len(t, c.Changes, 1)
+	assert.Equal(t, "$ref", c.Changes[0].Keyword)
+	assert.Equal(t, "https://example.com/schemas/a", c.Changes[0].Old)
+	assert.Equal(t, "https://example.com/schemas/b", c.Changes[0].New)
+	assert.True(t, c.Changes[0].Breaking())
+}
+
+func ptrUint(v uint64) *uint64 {
+	return &v
+}
+
+func propertiesOf(key string, s *Schema) *orderedmap.OrderedMap[string, *Schema] {
+	props := NewProperties()
+	props.Set(key, s)
+	return props
+}
@@ -0,0 +1,151 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// Condition describes a rule that applies additional constraints to the
+// properties of a struct, when some of its other properties have specific
+// values. Property names may be provided either as their JSON names, or as
+// the names of the Go fields they are defined by. Conditions that refer to
+// unknown properties are skipped, and reported by the TryReflect methods.
+type Condition struct {
+	// When maps property names to the value they must have for the
+	// condition to apply. A slice of values will match any of them.
+	When map[string]any
+	// Require lists the properties that must be present when the condition
+	// applies.
+	Require []string
+	// Then and Else define optional schemas to be applied when the condition
+	// does, or does not, apply.
+	Then *Schema
+	Else *Schema
+}
+
+// If an object to be reflected defines a `JSONSchemaConditions` method, the
+// conditions it provides will be added to its schema using `if`, `then`, and
+// `else` keywords, combined with `allOf` when there is more than one.
+type conditionsSchemaImpl interface {
+	JSONSchemaConditions() []Condition
+}
+
+var conditionsType = reflect.TypeOf((*conditionsSchemaImpl)(nil)).Elem()
+
+// reflectConditions adds the conditions defined by the struct type, if any,
+// to its schema.
+func (r *Reflector) reflectConditions(t reflect.Type, s *Schema) {
+	if !t.Implements(conditionsType) {
+		return
+	}
+	o := reflect.New(t).Interface().(conditionsSchemaImpl)
+	conditions := o.JSONSchemaConditions()
+	if len(conditions) == 0 {
+		return
+	}
+	names := make(map[string]string)
+	r.conditionPropertyNames(t, names)
+
+	list := make([]*Schema, 0, len(conditions))
+	for _, c := range conditions {
+		cond, err := conditionSchema(t, names, c)
+		if err != nil {
+			r.addError(err)
+			continue
+		}
+		list = append(list, cond)
+	}
+	if len(list) == 0 {
+		return
+	}
+	if len(list) == 1 && s.If == nil && s.Then == nil && s.Else == nil {
+		s.If, s.Then, s.Else = list[0].If, list[0].Then, list[0].Else
+		return
+	}
+	s.AllOf = append(s.AllOf, list...)
+}
+
+// conditionPropertyNames maps the JSON names of the struct's properties, and
+// the names of the Go fields that define them, to the JSON names.
+func (r *Reflector) conditionPropertyNames(t reflect.Type, names map[string]string) {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return
+	}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, shouldEmbed, _, _ := r.reflectFieldName(f)
+		if name == "" {
+			if shouldEmbed {
+				r.conditionPropertyNames(f.Type, names)
+			}
+			continue
+		}
+		names[name] = name
+		if _, ok := names[f.Name]; !ok {
+			names[f.Name] = name
+		}
+	}
+}
+
+// conditionSchema provides the schema for the condition, or an error if it
+// refers to properties the struct does not have.
+func conditionSchema(t reflect.Type, names map[string]string, c Condition) (*Schema, error) {
+	var unknown []string
+	property := func(n string) string {
+		name, ok := names[n]
+		if !ok {
+			unknown = appendUniqueString(unknown, n)
+		}
+		return name
+	}
+
+	cond := &Schema{
+		If:   &Schema{Properties: NewProperties()},
+		Then: c.Then.clone(),
+		Else: c.Else.clone(),
+	}
+	for _, k := range sortedKeys(c.When) {
+		name := property(k)
+		cond.If.Properties.Set(name, conditionValueSchema(c.When[k]))
+		cond.If.Required = append(cond.If.Required, name)
+	}
+	if len(c.Require) > 0 {
+		if cond.Then == nil {
+			cond.Then = &Schema{}
+		}
+		for _, n := range c.Require {
+			cond.Then.Required = appendUniqueString(cond.Then.Required, property(n))
+		}
+	}
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("%s: condition refers to unknown properties %q", fullyQualifiedTypeName(t), unknown)
+	}
+	return cond, nil
+}
+
+// conditionValueSchema provides the schema that matches the value, or any of
+// the values in a slice.
+func conditionValueSchema(v any) *Schema {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+		if _, isBytes := v.([]byte); !isBytes {
+			s := &Schema{Enum: make([]any, rv.Len())}
+			for i := range s.Enum {
+				s.Enum[i] = conditionValue(rv.Index(i).Interface())
+			}
+			return s
+		}
+	}
+	return &Schema{Const: conditionValue(v)}
+}
+
+func conditionValue(v any) any {
+	if v == nil {
+		return json.RawMessage("null")
+	}
+	return v
+}
@@ -0,0 +1,72 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// Embedded holds a value that is encoded as a JSON document inside a string,
+// like `"{\"id\":1}"`, which is common when a payload needs to be passed
+// through unchanged. Its schema will be a string with an `application/json`
+// media type, and a `contentSchema` reflected from the type of the value.
+type Embedded[T any] struct {
+	Value T
+}
+
+// MarshalJSON encodes the value as JSON, and then as a string.
+func (e Embedded[T]) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(e.Value)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(string(data))
+}
+
+// UnmarshalJSON decodes the string, and then the JSON document it contains.
+func (e *Embedded[T]) UnmarshalJSON(data []byte) error {
+	var doc string
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	return json.Unmarshal([]byte(doc), &e.Value)
+}
+
+func (Embedded[T]) contentType() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+// embeddedContent is implemented by all the instances of Embedded, so that
+// the type of their content can be determined.
+type embeddedContent interface {
+	contentType() reflect.Type
+}
+
+var embeddedType = reflect.TypeOf((*embeddedContent)(nil)).Elem()
+
+// reflectEmbeddedContent describes an Embedded type as a string containing
+// a JSON document that matches the schema of its value.
+func (r *Reflector) reflectEmbeddedContent(definitions Definitions, t reflect.Type, st *Schema) {
+	ct := reflect.New(t).Elem().Interface().(embeddedContent).contentType()
+	st.Type = "string"
+	st.ContentMediaType = "application/json"
+	st.ContentSchema = r.refOrReflectTypeToSchema(definitions, ct)
+}
+
+// contentSchemaKeyword sets the schema of the document contained in a string,
+// using the type registered in ContentSchemaTypes under the name provided in
+// the `contentSchema` tag.
+func (r *Reflector) contentSchemaKeyword(definitions Definitions, t *Schema, f reflect.StructField) {
+	if t.Type != "string" {
+		return
+	}
+	for _, tag := range splitOnUnescapedCommas(f.Tag.Get("jsonschema")) {
+		name, val, ok := strings.Cut(tag, "=")
+		if !ok || name != "contentSchema" {
+			continue
+		}
+		if v, ok := r.ContentSchemaTypes[val]; ok && v != nil {
+			t.ContentSchema = r.refOrReflectTypeToSchema(definitions, reflect.TypeOf(v))
+		}
+	}
+}
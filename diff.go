@@ -0,0 +1,306 @@
+package jsonschema
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	orderedmap "github.com/pb33f/ordered-map/v2"
+)
+
+// DiffKind describes the type of difference found between two schemas.
+type DiffKind string
+
+// Kinds of differences reported by Diff.
+const (
+	DiffAdded     DiffKind = "added"
+	DiffRemoved   DiffKind = "removed"
+	DiffChanged   DiffKind = "changed"
+	DiffReordered DiffKind = "reordered"
+)
+
+// Difference describes a single structural change between two schemas.
+type Difference struct {
+	// Kind of change.
+	Kind DiffKind `json:"kind"`
+	// Path is the JSON Pointer to the keyword or sub-schema affected.
+	Path string `json:"path"`
+	// Old contains the previous value, when removed, changed or reordered.
+	Old any `json:"old,omitempty"`
+	// New contains the new value, when added, changed or reordered.
+	New any `json:"new,omitempty"`
+}
+
+// Diff compares two schema documents keyword by keyword, and provides the
+// list of structural differences between them, in a consistent order. Unlike
+// Compare, references are not followed, and no attempt is made to determine
+// if changes are compatible.
+//
+// Properties are matched by name, and if the order of the properties shared
+// by both schemas changes, a DiffReordered difference will be included. The
+// `required` and `enum` keywords, and `type` when either schema allows a list
+// of types, are treated as sets, so only their added or removed values are
+// reported, each with the keyword's path.
+func Diff(a, b *Schema) []*Difference {
+	d := new(differ)
+	d.diffSchemas("", a, b)
+	return d.list
+}
+
+// String provides a single line description of the difference.
+func (d *Difference) String() string {
+	path := d.Path
+	if path == "" {
+		path = "/"
+	}
+	switch d.Kind {
+	case DiffAdded:
+		return fmt.Sprintf("+ %s: %s", path, valueKey(d.New))
+	case DiffRemoved:
+		return fmt.Sprintf("- %s: %s", path, valueKey(d.Old))
+	case DiffReordered:
+		return fmt.Sprintf("~ %s: order %s -> %s", path, valueKey(d.Old), valueKey(d.New))
+	default:
+		return fmt.Sprintf("~ %s: %s -> %s", path, valueKey(d.Old), valueKey(d.New))
+	}
+}
+
+// WriteDiff renders the list of differences as text, one per line, using
+// `+` for additions, `-` for removals, and `~` for changes.
+func WriteDiff(w io.Writer, diffs []*Difference) error {
+	for _, d := range diffs {
+		if _, err := io.WriteString(w, d.String()+"\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type differ struct {
+	list []*Difference
+}
+
+func (d *differ) add(kind DiffKind, path string, o, n any) {
+	d.list = append(d.list, &Difference{Kind: kind, Path: path, Old: o, New: n})
+}
+
+func (d *differ) diffSchemas(path string, a, b *Schema) {
+	switch {
+	case a == nil && b == nil:
+		return
+	case a == nil:
+		d.add(DiffAdded, path, nil, b)
+		return
+	case b == nil:
+		d.add(DiffRemoved, path, a, nil)
+		return
+	case a.boolean != nil || b.boolean != nil:
+		if valueKey(a) != valueKey(b) {
+			d.add(DiffChanged, path, a, b)
+		}
+		return
+	}
+
+	ak, bk := schemaKeywords(a), schemaKeywords(b)
+	for _, kw := range mergeKeywordOrder(ak, bk) {
+		p := path + "/" + escapePointer(kw.name)
+		av, aok := ak.get(kw.name)
+		bv, bok := bk.get(kw.name)
+		switch {
+		case !aok:
+			d.add(DiffAdded, p, nil, bv)
+		case !bok:
+			d.add(DiffRemoved, p, av, nil)
+		default:
+			d.diffValues(p, kw.name, av, bv)
+		}
+	}
+}
+
+func (d *differ) diffValues(path, keyword string, a, b any) {
+	switch av := a.(type) {
+	case *Schema:
+		d.diffSchemas(path, av, b.(*Schema))
+	case []*Schema:
+		d.diffSchemaLists(path, av, b.([]*Schema))
+	case map[string]*Schema:
+		d.diffSchemaMaps(path, av, b.(map[string]*Schema))
+	case *orderedmap.OrderedMap[string, *Schema]:
+		d.diffProperties(path, av, b.(*orderedmap.OrderedMap[string, *Schema]))
+	default:
+		if keyword == "required" || keyword == "enum" {
+			d.diffSets(path, a, b)
+			return
+		}
+		_, aList := a.([]string)
+		_, bList := b.([]string)
+		if keyword == "type" && (aList || bList) {
+			d.diffSets(path, a, b)
+			return
+		}
+		if valueKey(a) != valueKey(b) {
+			d.add(DiffChanged, path, a, b)
+		}
+	}
+}
+
+func (d *differ) diffSchemaLists(path string, a, b []*Schema) {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		var as, bs *Schema
+		if i < len(a) {
+			as = a[i]
+		}
+		if i < len(b) {
+			bs = b[i]
+		}
+		d.diffSchemas(path+"/"+strconv.Itoa(i), as, bs)
+	}
+}
+
+func (d *differ) diffSchemaMaps(path string, a, b map[string]*Schema) {
+	for _, k := range sortedKeys(mergeKeys(a, b)) {
+		d.diffSchemas(path+"/"+escapePointer(k), a[k], b[k])
+	}
+}
+
+func (d *differ) diffProperties(path string, a, b *orderedmap.OrderedMap[string, *Schema]) {
+	var aOrder, bOrder []string
+	for pair := a.Oldest(); pair != nil; pair = pair.Next() {
+		bs, ok := b.Get(pair.Key)
+		if ok {
+			aOrder = append(aOrder, pair.Key)
+		}
+		d.diffSchemas(path+"/"+escapePointer(pair.Key), pair.Value, bs)
+	}
+	for pair := b.Oldest(); pair != nil; pair = pair.Next() {
+		if _, ok := a.Get(pair.Key); ok {
+			bOrder = append(bOrder, pair.Key)
+			continue
+		}
+		d.diffSchemas(path+"/"+escapePointer(pair.Key), nil, pair.Value)
+	}
+	if !reflect.DeepEqual(aOrder, bOrder) {
+		d.add(DiffReordered, path, aOrder, bOrder)
+	}
+}
+
+// diffSets compares two lists of values ignoring their order.
+func (d *differ) diffSets(path string, a, b any) {
+	al, bl := anyList(a), anyList(b)
+	as, bs := valueSet(al), valueSet(bl)
+	for _, v := range al {
+		if !bs[valueKey(v)] {
+			d.add(DiffRemoved, path, v, nil)
+		}
+	}
+	for _, v := range bl {
+		if !as[valueKey(v)] {
+			d.add(DiffAdded, path, nil, v)
+		}
+	}
+}
+
+func anyList(v any) []any {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice {
+		return []any{v}
+	}
+	list := make([]any, rv.Len())
+	for i := range list {
+		list[i] = rv.Index(i).Interface()
+	}
+	return list
+}
+
+// keywordValue is a single keyword of a schema with its value.
+type keywordValue struct {
+	name  string
+	value any
+}
+
+type keywordList []keywordValue
+
+func (l keywordList) get(name string) (any, bool) {
+	for _, kw := range l {
+		if kw.name == name {
+			return kw.value, true
+		}
+	}
+	return nil, false
+}
+
+var schemaType = reflect.TypeOf(Schema{})
+
+// schemaKeywords provides the list of keywords defined in the schema, in the
+// same order they are serialized, followed by any extras sorted by name.
+// Maps of sub-schemas are all provided as `map[string]*Schema`.
+func schemaKeywords(s *Schema) keywordList {
+	var list keywordList
+	v := reflect.ValueOf(s).Elem()
+	for i := 0; i < schemaType.NumField(); i++ {
+		f := schemaType.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if !f.IsExported() || name == "-" || name == "" {
+			continue
+		}
+		if name == "type" {
+			// may be provided as a list by the Types field
+			if t := s.typeValue(); t != "" {
+				list = append(list, keywordValue{name, t})
+			}
+			continue
+		}
+		fv := v.Field(i)
+		if fv.IsZero() || ((fv.Kind() == reflect.Map || fv.Kind() == reflect.Slice) && fv.Len() == 0) {
+			continue
+		}
+		val := fv.Interface()
+		switch m := val.(type) {
+		case Definitions:
+			val = map[string]*Schema(m)
+		case *orderedmap.OrderedMap[string, *Schema]:
+			if m.Len() == 0 {
+				continue
+			}
+		}
+		list = append(list, keywordValue{name, val})
+	}
+	for _, k := range sortedKeys(s.Extras) {
+		list = append(list, keywordValue{k, s.Extras[k]})
+	}
+	return list
+}
+
+// mergeKeywordOrder combines the keywords of both lists, in the order they
+// would be serialized.
+func mergeKeywordOrder(a, b keywordList) keywordList {
+	list := append(keywordList{}, a...)
+	for _, kw := range b {
+		if _, ok := a.get(kw.name); !ok {
+			list = append(list, kw)
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		ri, rj := keywordRank(list[i].name), keywordRank(list[j].name)
+		if ri != rj {
+			return ri < rj
+		}
+		return list[i].name < list[j].name
+	})
+	return list
+}
+
+// keywordRank provides the position of the keyword's field in the Schema
+// struct, or a position after all the fields for extras.
+func keywordRank(name string) int {
+	for i := 0; i < schemaType.NumField(); i++ {
+		n, _, _ := strings.Cut(schemaType.Field(i).Tag.Get("json"), ",")
+		if n == name {
+			return i
+		}
+	}
+	return schemaType.NumField()
+}
@@ -0,0 +1,98 @@
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	r := &Reflector{}
+	a := r.Reflect(&CompareUserV1{})
+	b := r.Reflect(&CompareUserV2{})
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, WriteDiff(buf, Diff(a, b)))
+	assert.Equal(t, `~ /$id: "https://github.com/invopop/jsonschema/compare-user-v1" -> "https://github.com/invopop/jsonschema/compare-user-v2"
+~ /$ref: "#/$defs/CompareUserV1" -> "#/$defs/CompareUserV2"
+- /$defs/CompareAddressV1: {"properties":{"street":{"type":"string","maxLength":100},"zip":{"type":"string"}},"additionalProperties":false,"type":"object","required":["street"]}
++ /$defs/CompareAddressV2: {"properties":{"street":{"type":"string","maxLength":50},"zip":{"type":"string"}},"additionalProperties":false,"type":"object","required":["street","zip"]}
+- /$defs/CompareUserV1: {"properties":{"name":{"type":"string","maxLength":20},"kind":{"type":"string","enum":["person","company"]},"age":{"type":"integer","minimum":0},"email":{"type":"string"},"address":{"$ref":"#/$defs/CompareAddressV1"},"friends":{"items":{"$ref":"#/$defs/CompareUserV1"},"type":"array"}},"additionalProperties":false,"type":"object","required":["name","kind","address"]}
++ /$defs/CompareUserV2: {"properties":{"name":{"type":"string","maxLength":20,"description":"Full name"},"kind":{"type":"string","enum":["person","company","government"]},"age":{"type":"number","minimum":18},"phone":{"type":"string"},"address":{"$ref":"#/$defs/CompareAddressV2"},"friends":{"items":{"$ref":"#/$defs/CompareUserV2"},"type":"array"}},"additionalProperties":false,"type":"object","required":["name","kind","address"]}
+`, buf.String())
+
+	a = a.Definitions["CompareUserV1"]
+	b = b.Definitions["CompareUserV2"]
+	buf.Reset()
+	require.NoError(t, WriteDiff(buf, Diff(a, b)))
+	assert.Equal(t, `+ /properties/name/description: "Full name"
++ /properties/kind/enum: "government"
+~ /properties/age/type: "integer" -> "number"
+~ /properties/age/minimum: 0 -> 18
+- /properties/email: {"type":"string"}
+~ /properties/address/$ref: "#/$defs/CompareAddressV1" -> "#/$defs/CompareAddressV2"
+~ /properties/friends/items/$ref: "#/$defs/CompareUserV1" -> "#/$defs/CompareUserV2"
++ /properties/phone: {"type":"string"}
+`, buf.String())
+}
+
+func TestDiffSetsAndOrder(t *testing.T) {
+	a := &Schema{
+		Type:       "object",
+		Properties: NewProperties(),
+		Required:   []string{"a", "b"},
+		Extras:     map[string]any{"x-foo": "bar"},
+	}
+	a.Properties.Set("a", &Schema{Type: "string"})
+	a.Properties.Set("b", &Schema{Type: "string"})
+	a.Properties.Set("c", &Schema{Type: "string"})
+	b := &Schema{
+		Type:                 "object",
+		Properties:           NewProperties(),
+		Required:             []string{"b", "c"},
+		AdditionalProperties: FalseSchema,
+		Extras:               map[string]any{"x-foo": "baz"},
+	}
+	b.Properties.Set("b", &Schema{Type: "string"})
+	b.Properties.Set("a", &Schema{Type: "string"})
+	b.Properties.Set("c", &Schema{Type: "string"})
+
+	diffs := Diff(a, b)
+	assert.Equal(t, []*Difference{
+		{Kind: DiffReordered, Path: "/properties", Old: []string{"a", "b", "c"}, New: []string{"b", "a", "c"}},
+		{Kind: DiffAdded, Path: "/additionalProperties", New: FalseSchema},
+		{Kind: DiffRemoved, Path: "/required", Old: "a"},
+		{Kind: DiffAdded, Path: "/required", New: "c"},
+		{Kind: DiffChanged, Path: "/x-foo", Old: "bar", New: "baz"},
+	}, diffs)
+
+	data, err := json.Marshal(diffs[1])
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"kind":"added","path":"/additionalProperties","new":false}`, string(data))
+
+	assert.Equal(t, "~ /properties: order [\"a\",\"b\",\"c\"] -> [\"b\",\"a\",\"c\"]", diffs[0].String())
+	assert.Empty(t, Diff(a, a))
+	assert.Equal(t, "~ /: true -> false", Diff(TrueSchema, FalseSchema)[0].String())
+}
+
+func TestDiffTypeList(t *testing.T) {
+	a := mergeTestSchema(t, `{"type":["string","null"]}`)
+	b := mergeTestSchema(t, `{"type":["string","integer"]}`)
+	buf := new(bytes.Buffer)
+	require.NoError(t, WriteDiff(buf, Diff(a, b)))
+	assert.Equal(t, `- /type: "null"
++ /type: "integer"
+`, buf.String())
+
+	b = mergeTestSchema(t, `{"type":"string"}`)
+	buf.Reset()
+	require.NoError(t, WriteDiff(buf, Diff(a, b)))
+	assert.Equal(t, `- /type: "null"
+`, buf.String())
+
+	assert.Empty(t, Diff(a, mergeTestSchema(t, `{"type":["string","null"]}`)))
+	assert.Empty(t, Diff(a, mergeTestSchema(t, `{"type":["null","string"]}`)))
+}
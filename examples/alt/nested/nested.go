@@ -0,0 +1,8 @@
+// Package nested contains sample types sharing their package name with
+// another, used to exercise the naming of schema definitions.
+package nested
+
+// Pet defines an alternative pet with the same name.
+type Pet struct {
+	Breed string `json:"breed"`
+}
@@ -0,0 +1,98 @@
+package jsonschema
+
+import (
+	"reflect"
+	"strings"
+)
+
+// inlineSingleUseDefinitions replaces the references to root definitions that
+// are only used once, and are not part of a recursive chain of references,
+// with the definitions themselves, removing them from `$defs`. Definitions
+// named in the keep list, the definition referenced by the root itself, and
+// those that references point inside of are never inlined.
+func inlineSingleUseDefinitions(s *Schema, keep []string) {
+	if len(s.Definitions) == 0 {
+		return
+	}
+	uses := make(map[string]int)
+	deps := make(map[string][]string)
+	pinned := make(map[string]bool)
+	s.walk("", func(ptr string, x *Schema) bool {
+		if name := definitionRefName(x.Ref); name != "" && s.Definitions[name] != nil {
+			uses[name]++
+			if from := rootDefinitionName(ptr); from != "" {
+				deps[from] = append(deps[from], name)
+			}
+		} else if strings.HasPrefix(x.Ref, defsRefPrefix) {
+			pinned[rootDefinitionName(strings.TrimPrefix(x.Ref, "#"))] = true
+		}
+		return true
+	})
+
+	inline := make(map[string]bool)
+	for name, n := range uses {
+		if n == 1 && !pinned[name] && !referencesItself(name, deps) {
+			inline[name] = true
+		}
+	}
+	delete(inline, definitionRefName(s.Ref))
+	for _, name := range keep {
+		delete(inline, name)
+	}
+
+	s.walk("", func(_ string, x *Schema) bool {
+		name := definitionRefName(x.Ref)
+		if !inline[name] {
+			return true
+		}
+		def := s.Definitions[name]
+		defs := x.Definitions
+		siblings := *x
+		siblings.Ref = ""
+		siblings.Definitions = nil
+		if !reflect.DeepEqual(&siblings, &Schema{}) {
+			// keywords alongside the reference apply too, and take priority
+			merged, err := Merge(&siblings, def)
+			if err != nil {
+				return true
+			}
+			def = merged
+		}
+		*x = *def
+		x.Definitions = defs
+		delete(s.Definitions, name)
+		return true
+	})
+}
+
+// definitionRefName provides the name of the root definition the reference
+// points to directly, if any.
+func definitionRefName(ref string) string {
+	if !strings.HasPrefix(ref, defsRefPrefix) {
+		return ""
+	}
+	name := strings.TrimPrefix(ref, defsRefPrefix)
+	if strings.Contains(name, "/") {
+		return ""
+	}
+	return unescapePointer(name)
+}
+
+// referencesItself determines if the named definition can be reached by
+// following the references from its own sub-schemas.
+func referencesItself(name string, deps map[string][]string) bool {
+	seen := make(map[string]bool)
+	pending := append([]string{}, deps[name]...)
+	for len(pending) > 0 {
+		n := pending[0]
+		pending = pending[1:]
+		if n == name {
+			return true
+		}
+		if !seen[n] {
+			seen[n] = true
+			pending = append(pending, deps[n]...)
+		}
+	}
+	return false
+}
@@ -0,0 +1,169 @@
+package jsonschema
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// splitJSONTag splits the json tag into the field's name, followed by its
+// options, using the syntax of encoding/json/v2 when enabled, where names
+// may be quoted with single quotes to include commas or other characters.
+func (r *Reflector) splitJSONTag(tag string) []string {
+	if !r.JSONv2 || !strings.HasPrefix(tag, "'") {
+		return strings.Split(tag, ",")
+	}
+	for i := 1; i < len(tag); i++ {
+		switch tag[i] {
+		case '\\':
+			i++
+		case '\'':
+			name, err := strconv.Unquote(`"` + strings.ReplaceAll(tag[1:i], `\'`, `'`) + `"`)
+			if err != nil {
+				name = tag[1:i]
+			}
+			rest := strings.TrimPrefix(tag[i+1:], ",")
+			if rest == "" {
+				return []string{name}
+			}
+			return append([]string{name}, strings.Split(rest, ",")...)
+		}
+	}
+	return strings.Split(tag, ",")
+}
+
+// jsonTagFormat provides the value of the `format:` option of the json tag,
+// as defined by encoding/json/v2.
+func jsonTagFormat(tags []string) string {
+	for _, tag := range tags[1:] {
+		if format, ok := strings.CutPrefix(tag, "format:"); ok {
+			return format
+		}
+	}
+	return ""
+}
+
+// neverEmptyJSONv2 determines if values of the type are never omitted by the
+// `omitempty` option of encoding/json/v2, which only applies to values encoded
+// as null, or an empty string, object, or array. Numbers and booleans are
+// always included, unless encoded by a custom marshaler.
+func neverEmptyJSONv2(t reflect.Type) bool {
+	pt := reflect.PointerTo(t)
+	if pt.Implements(jsonMarshalerType) || pt.Implements(textMarshalerType) {
+		return false
+	}
+	switch t.Kind() {
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
+
+// inlinedByJSONv2Tags determines if the field's members are promoted to the
+// struct containing it, using the `inline` option, or the `unknown` option of
+// encoding/json/v2.
+func (r *Reflector) inlinedByJSONv2Tags(tags []string) bool {
+	if inlinedByJSONTags(tags) {
+		return true
+	}
+	return r.JSONv2 && jsonTagHasOption(tags, "unknown")
+}
+
+// reflectJSONv2Fallback handles the fields that encoding/json/v2 uses to hold
+// the object members that do not match any other field, by allowing them as
+// additional properties of the struct. Fallback fields are maps with string
+// keys, or raw JSON values, that are either tagged with the `unknown` option,
+// or promoted into the struct.
+func (r *Reflector) reflectJSONv2Fallback(definitions Definitions, st *Schema, f reflect.StructField) bool {
+	tags := r.splitJSONTag(f.Tag.Get(r.fieldNameTag()))
+	if ignoredByJSONTags(tags) {
+		return false
+	}
+	if !r.inlinedByJSONv2Tags(tags) && !(f.Anonymous && tags[0] == "") {
+		return false
+	}
+	t := f.Type
+	if t.Kind() == reflect.Ptr && t.Name() == "" {
+		t = t.Elem()
+	}
+	switch {
+	case t == rawMessageType, t.PkgPath() == "encoding/json/jsontext" && t.Name() == "Value":
+		st.AdditionalProperties = nil
+	case t.Kind() == reflect.Map && t.Key().Kind() == reflect.String:
+		st.AdditionalProperties = nil
+		if t.Elem().Kind() != reflect.Interface {
+			st.AdditionalProperties = r.refOrReflectTypeToSchema(definitions, t.Elem())
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+// jsonV2FieldSchema provides the schema of the field's value as encoded by
+// encoding/json/v2, according to its `format:` option, or nil if it is the
+// same as the one reflected from its type.
+func (r *Reflector) jsonV2FieldSchema(f reflect.StructField, s *Schema) *Schema {
+	format := jsonTagFormat(r.splitJSONTag(f.Tag.Get(r.fieldNameTag())))
+	t := f.Type
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		switch format {
+		case "", "RFC3339", "RFC3339Nano":
+			return nil
+		case "unix", "unixmilli", "unixmicro", "unixnano":
+			return &Schema{Type: "number"}
+		case "DateOnly":
+			return &Schema{Type: "string", Format: "date"}
+		default:
+			return &Schema{Type: "string"}
+		}
+	case t == durationType:
+		switch format {
+		case "units":
+			return &Schema{Type: "string"}
+		case "iso8601":
+			return &Schema{Type: "string", Format: "duration"}
+		case "sec", "milli", "micro", "nano":
+			return &Schema{Type: "number"}
+		}
+	case (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) && t.Elem().Kind() == reflect.Uint8 && t != rawMessageType:
+		// byte arrays are also encoded as base64 by default
+		switch format {
+		case "", "base64":
+			if t.Kind() == reflect.Slice {
+				return nil
+			}
+			return &Schema{Type: "string", ContentEncoding: "base64"}
+		case "base64url", "base32", "base32hex":
+			return &Schema{Type: "string", ContentEncoding: format}
+		case "base16", "hex":
+			return &Schema{Type: "string", ContentEncoding: "base16"}
+		case "array":
+			a := &Schema{Type: "array", Items: &Schema{Type: "integer", Minimum: "0", Maximum: "255"}}
+			if t.Kind() == reflect.Array {
+				l := uint64(t.Len())
+				a.MinItems, a.MaxItems = &l, &l
+			}
+			return a
+		}
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		if format == "nonfinite" {
+			return &Schema{AnyOf: []*Schema{
+				s,
+				{Type: "string", Enum: []any{"NaN", "Infinity", "-Infinity"}},
+			}}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,501 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strconv"
+)
+
+// Merge combines two schemas into a single schema that only accepts values
+// valid for both of them, so that `Merge(a, b)` is equivalent to
+// `{"allOf": [a, b]}`:
+//
+//   - lower bounds take the largest value, and upper bounds the smallest,
+//   - `required` is the union of both lists,
+//   - `enum` contains the values present in both lists,
+//   - properties with the same name are merged recursively,
+//   - annotations, like the title, are taken from the first schema that
+//     defines them.
+//
+// Keywords that cannot be combined, like two different patterns or
+// references, are kept in an `allOf` list. Constraints that cannot be
+// satisfied at the same time, like incompatible types, are reported as
+// errors. Neither of the original schemas is modified.
+func Merge(a, b *Schema) (*Schema, error) {
+	m := new(merger)
+	s := m.merge("", a, b)
+	if len(m.errs) > 0 {
+		return nil, errors.Join(m.errs...)
+	}
+	return s, nil
+}
+
+// FlattenAllOf provides a copy of the schema in which every schema that
+// contains an `allOf` list is merged with each of the sub-schemas in the
+// list, using the same rules as Merge. References to the root schema's
+// definitions inside `allOf` lists are replaced by the definitions they
+// point to, unless they are recursive. Sub-schemas that cannot be merged
+// will be kept in `allOf`.
+func FlattenAllOf(s *Schema) (*Schema, error) {
+	m := new(merger)
+	c := s.clone()
+	c.walk("", func(ptr string, x *Schema) bool {
+		if len(x.AllOf) > 0 {
+			*x = *m.flatten(ptr, c, x)
+		}
+		return true
+	})
+	if len(m.errs) > 0 {
+		return nil, errors.Join(m.errs...)
+	}
+	return c, nil
+}
+
+type merger struct {
+	errs []error
+}
+
+func (m *merger) errorf(path, format string, args ...any) {
+	if path == "" {
+		path = "/"
+	}
+	m.errs = append(m.errs, fmt.Errorf("%s: %s", path, fmt.Sprintf(format, args...)))
+}
+
+// flatten merges the schema's allOf sub-schemas into a new schema.
+func (m *merger) flatten(path string, root, s *Schema) *Schema {
+	result := *s
+	result.AllOf = nil
+	r := &result
+	var kept []*Schema
+	seen := make(map[string]bool)
+	pending := s.AllOf
+	for len(pending) > 0 {
+		item := pending[0]
+		pending = pending[1:]
+		if def := resolveDefinitionRef(root, item); def != item {
+			if seen[item.Ref] {
+				kept = append(kept, item)
+				continue
+			}
+			seen[item.Ref] = true
+			item = def
+		}
+		if len(item.AllOf) > 0 {
+			pending = append(pending, item.AllOf...)
+			c := *item
+			c.AllOf = nil
+			item = &c
+		}
+		r = m.merge(path, r, item)
+	}
+	if r.boolean == nil {
+		r.AllOf = append(r.AllOf, kept...)
+	}
+	return r
+}
+
+func (m *merger) merge(path string, a, b *Schema) *Schema {
+	switch {
+	case a == nil || isTrueSchema(a):
+		return b.clone()
+	case b == nil || isTrueSchema(b):
+		return a.clone()
+	case isFalseSchema(a) || isFalseSchema(b):
+		return FalseSchema.clone()
+	}
+
+	s := new(Schema)
+	m.mergeCore(s, a, b)
+	m.mergeAnnotations(s, a, b)
+	m.mergeValues(path, s, a, b)
+	m.mergeNumbers(path, s, a, b)
+	m.mergeStrings(path, s, a, b)
+	m.mergeArrays(path, s, a, b)
+	m.mergeObjects(path, s, a, b)
+	m.mergeLogic(s, a, b)
+	return s
+}
+
+// mergeCore handles the identifiers, references and definitions.
+func (m *merger) mergeCore(s, a, b *Schema) {
+	s.Version = firstString(a.Version, b.Version)
+	s.ID = ID(firstString(a.ID.String(), b.ID.String()))
+	s.Anchor = firstString(a.Anchor, b.Anchor)
+	s.DynamicRef = firstString(a.DynamicRef, b.DynamicRef)
+	s.Ref = a.Ref
+	if b.Ref != "" && b.Ref != a.Ref {
+		if s.Ref == "" {
+			s.Ref = b.Ref
+		} else {
+			s.AllOf = append(s.AllOf, &Schema{Ref: b.Ref})
+		}
+	}
+	if a.Definitions != nil || b.Definitions != nil {
+		s.Definitions = cloneSchemaMap(b.Definitions)
+		if s.Definitions == nil {
+			s.Definitions = Definitions{}
+		}
+		for k, d := range a.Definitions {
+			s.Definitions[k] = d.clone()
+		}
+	}
+	if a.Extras != nil || b.Extras != nil {
+		s.Extras = make(map[string]any)
+		for k, v := range b.Extras {
+			s.Extras[k] = v
+		}
+		for k, v := range a.Extras {
+			s.Extras[k] = v
+		}
+	}
+}
+
+func (m *merger) mergeAnnotations(s, a, b *Schema) {
+	s.Comments = firstString(a.Comments, b.Comments)
+	s.Title = firstString(a.Title, b.Title)
+	s.Description = firstString(a.Description, b.Description)
+	s.Default = a.Default
+	if s.Default == nil {
+		s.Default = b.Default
+	}
+	s.Deprecated = a.Deprecated || b.Deprecated
+	s.ReadOnly = a.ReadOnly || b.ReadOnly
+	s.WriteOnly = a.WriteOnly || b.WriteOnly
+	s.Examples = append(append([]any{}, a.Examples...), b.Examples...)
+	if len(s.Examples) == 0 {
+		s.Examples = nil
+	}
+}
+
+func (m *merger) mergeValues(path string, s, a, b *Schema) {
+	if types, ok := mergeTypes(a, b); ok {
+		s.setTypes(types)
+	} else {
+		m.errorf(path, "type %q conflicts with %q", a.typeValue(), b.typeValue())
+	}
+
+	switch {
+	case a.Enum == nil:
+		s.Enum = append([]any(nil), b.Enum...)
+	case b.Enum == nil:
+		s.Enum = append([]any(nil), a.Enum...)
+	default:
+		in := valueSet(b.Enum)
+		for _, v := range a.Enum {
+			if in[valueKey(v)] {
+				s.Enum = append(s.Enum, v)
+			}
+		}
+		if len(s.Enum) == 0 {
+			m.errorf(path, "enums have no values in common")
+		}
+	}
+
+	switch {
+	case a.Const == nil:
+		s.Const = b.Const
+	case b.Const == nil || valueKey(a.Const) == valueKey(b.Const):
+		s.Const = a.Const
+	default:
+		m.errorf(path, "const %s conflicts with %s", valueKey(a.Const), valueKey(b.Const))
+	}
+}
+
+// mergeTypes provides the types allowed by both schemas, where the "number"
+// type only allows integers when the other schema requires them.
+func mergeTypes(a, b *Schema) ([]string, bool) {
+	at, bt := a.typeList(), b.typeList()
+	switch {
+	case len(bt) == 0:
+		return append([]string(nil), at...), true
+	case len(at) == 0:
+		return append([]string(nil), bt...), true
+	}
+	var types []string
+	for _, ty := range at {
+		switch {
+		case b.allowsType(ty):
+			types = appendUniqueString(types, ty)
+		case ty == "number" && b.allowsType("integer"):
+			types = appendUniqueString(types, "integer")
+		}
+	}
+	return types, len(types) > 0
+}
+
+func (m *merger) mergeNumbers(path string, s, a, b *Schema) {
+	s.Minimum = mergeBound(a.Minimum, b.Minimum, true)
+	s.ExclusiveMinimum = mergeBound(a.ExclusiveMinimum, b.ExclusiveMinimum, true)
+	s.Maximum = mergeBound(a.Maximum, b.Maximum, false)
+	s.ExclusiveMaximum = mergeBound(a.ExclusiveMaximum, b.ExclusiveMaximum, false)
+	m.checkRange(path, "minimum", "maximum", s.Minimum, s.Maximum)
+
+	s.MultipleOf = a.MultipleOf
+	if b.MultipleOf != "" && b.MultipleOf != a.MultipleOf {
+		if s.MultipleOf == "" {
+			s.MultipleOf = b.MultipleOf
+		} else {
+			s.AllOf = append(s.AllOf, &Schema{MultipleOf: b.MultipleOf})
+		}
+	}
+}
+
+func (m *merger) mergeStrings(path string, s, a, b *Schema) {
+	s.MinLength = mergeUintBound(a.MinLength, b.MinLength, true)
+	s.MaxLength = mergeUintBound(a.MaxLength, b.MaxLength, false)
+	m.checkRange(path, "minLength", "maxLength", uintNumber(s.MinLength), uintNumber(s.MaxLength))
+
+	s.Pattern = a.Pattern
+	if b.Pattern != "" && b.Pattern != a.Pattern {
+		if s.Pattern == "" {
+			s.Pattern = b.Pattern
+		} else {
+			s.AllOf = append(s.AllOf, &Schema{Pattern: b.Pattern})
+		}
+	}
+	s.Format = a.Format
+	if b.Format != "" && b.Format != a.Format {
+		if s.Format == "" {
+			s.Format = b.Format
+		} else {
+			s.AllOf = append(s.AllOf, &Schema{Format: b.Format})
+		}
+	}
+
+	var ok bool
+	if s.ContentEncoding, ok = mergeEqualStrings(a.ContentEncoding, b.ContentEncoding); !ok {
+		m.errorf(path, "contentEncoding %q conflicts with %q", a.ContentEncoding, b.ContentEncoding)
+	}
+	if s.ContentMediaType, ok = mergeEqualStrings(a.ContentMediaType, b.ContentMediaType); !ok {
+		m.errorf(path, "contentMediaType %q conflicts with %q", a.ContentMediaType, b.ContentMediaType)
+	}
+	s.ContentSchema = m.merge(path+"/contentSchema", a.ContentSchema, b.ContentSchema)
+}
+
+func (m *merger) mergeArrays(path string, s, a, b *Schema) {
+	s.MinItems = mergeUintBound(a.MinItems, b.MinItems, true)
+	s.MaxItems = mergeUintBound(a.MaxItems, b.MaxItems, false)
+	m.checkRange(path, "minItems", "maxItems", uintNumber(s.MinItems), uintNumber(s.MaxItems))
+	s.UniqueItems = a.UniqueItems || b.UniqueItems
+
+	for i := 0; i < len(a.PrefixItems) || i < len(b.PrefixItems); i++ {
+		ai, bi := a.Items, b.Items
+		if i < len(a.PrefixItems) {
+			ai = a.PrefixItems[i]
+		}
+		if i < len(b.PrefixItems) {
+			bi = b.PrefixItems[i]
+		}
+		s.PrefixItems = append(s.PrefixItems, m.merge(path+"/prefixItems/"+strconv.Itoa(i), ai, bi))
+	}
+	s.Items = m.merge(path+"/items", a.Items, b.Items)
+
+	// both contains conditions must be met separately
+	s.Contains = a.Contains.clone()
+	s.MinContains = a.MinContains
+	s.MaxContains = a.MaxContains
+	if b.Contains != nil {
+		if s.Contains == nil {
+			s.Contains = b.Contains.clone()
+			s.MinContains = b.MinContains
+			s.MaxContains = b.MaxContains
+		} else {
+			s.AllOf = append(s.AllOf, &Schema{Contains: b.Contains.clone(), MinContains: b.MinContains, MaxContains: b.MaxContains})
+		}
+	}
+}
+
+func (m *merger) mergeObjects(path string, s, a, b *Schema) {
+	s.MinProperties = mergeUintBound(a.MinProperties, b.MinProperties, true)
+	s.MaxProperties = mergeUintBound(a.MaxProperties, b.MaxProperties, false)
+	m.checkRange(path, "minProperties", "maxProperties", uintNumber(s.MinProperties), uintNumber(s.MaxProperties))
+
+	for _, r := range append(append([]string{}, a.Required...), b.Required...) {
+		s.Required = appendUniqueString(s.Required, r)
+	}
+	if a.DependentRequired != nil || b.DependentRequired != nil {
+		s.DependentRequired = make(map[string][]string)
+		for _, dr := range []map[string][]string{a.DependentRequired, b.DependentRequired} {
+			for k, list := range dr {
+				for _, r := range list {
+					s.DependentRequired[k] = appendUniqueString(s.DependentRequired[k], r)
+				}
+			}
+		}
+	}
+
+	m.mergeProperties(path, s, a, b)
+	s.PatternProperties = m.mergeSchemaMaps(path+"/patternProperties", a.PatternProperties, b.PatternProperties)
+	s.DependentSchemas = m.mergeSchemaMaps(path+"/dependentSchemas", a.DependentSchemas, b.DependentSchemas)
+	s.AdditionalProperties = m.merge(path+"/additionalProperties", a.AdditionalProperties, b.AdditionalProperties)
+	s.PropertyNames = m.merge(path+"/propertyNames", a.PropertyNames, b.PropertyNames)
+	s.UnevaluatedProperties = m.merge(path+"/unevaluatedProperties", a.UnevaluatedProperties, b.UnevaluatedProperties)
+}
+
+// mergeProperties combines the properties of both schemas, in order. Properties
+// only defined in one schema are merged with the other's additional
+// properties schema, if any, and are only reported when the result cannot be
+// satisfied while the property is required.
+func (m *merger) mergeProperties(path string, s, a, b *Schema) {
+	if a.Properties == nil && b.Properties == nil {
+		return
+	}
+	s.Properties = NewProperties()
+	add := func(name string, x, other, additional *Schema) {
+		p := path + "/properties/" + escapePointer(name)
+		if other == nil {
+			other = additional
+		}
+		ps := m.merge(p, x, other)
+		if isFalseSchema(ps) && !isFalseSchema(x) && slices.Contains(s.Required, name) {
+			m.errorf(p, "required property not allowed by additionalProperties")
+			return
+		}
+		s.Properties.Set(name, ps)
+	}
+	if a.Properties != nil {
+		for pair := a.Properties.Oldest(); pair != nil; pair = pair.Next() {
+			var other *Schema
+			if b.Properties != nil {
+				other, _ = b.Properties.Get(pair.Key)
+			}
+			add(pair.Key, pair.Value, other, b.AdditionalProperties)
+		}
+	}
+	if b.Properties != nil {
+		for pair := b.Properties.Oldest(); pair != nil; pair = pair.Next() {
+			if _, ok := s.Properties.Get(pair.Key); ok {
+				continue
+			}
+			if a.Properties != nil {
+				if _, ok := a.Properties.Get(pair.Key); ok {
+					continue
+				}
+			}
+			add(pair.Key, pair.Value, nil, a.AdditionalProperties)
+		}
+	}
+}
+
+func (m *merger) mergeSchemaMaps(path string, a, b map[string]*Schema) map[string]*Schema {
+	if a == nil && b == nil {
+		return nil
+	}
+	res := make(map[string]*Schema)
+	for _, k := range sortedKeys(mergeKeys(a, b)) {
+		res[k] = m.merge(path+"/"+escapePointer(k), a[k], b[k])
+	}
+	return res
+}
+
+func (m *merger) mergeLogic(s, a, b *Schema) {
+	s.AllOf = append(append(cloneSchemaList(a.AllOf), cloneSchemaList(b.AllOf)...), s.AllOf...)
+
+	s.AnyOf = cloneSchemaList(a.AnyOf)
+	if b.AnyOf != nil {
+		if s.AnyOf == nil {
+			s.AnyOf = cloneSchemaList(b.AnyOf)
+		} else {
+			s.AllOf = append(s.AllOf, &Schema{AnyOf: cloneSchemaList(b.AnyOf)})
+		}
+	}
+	s.OneOf = cloneSchemaList(a.OneOf)
+	if b.OneOf != nil {
+		if s.OneOf == nil {
+			s.OneOf = cloneSchemaList(b.OneOf)
+		} else {
+			s.AllOf = append(s.AllOf, &Schema{OneOf: cloneSchemaList(b.OneOf)})
+		}
+	}
+
+	s.Not = a.Not.clone()
+	if b.Not != nil {
+		if s.Not == nil {
+			s.Not = b.Not.clone()
+		} else {
+			s.Not = &Schema{AnyOf: []*Schema{s.Not, b.Not.clone()}}
+		}
+	}
+
+	s.If, s.Then, s.Else = a.If.clone(), a.Then.clone(), a.Else.clone()
+	if b.If != nil || b.Then != nil || b.Else != nil {
+		if s.If == nil && s.Then == nil && s.Else == nil {
+			s.If, s.Then, s.Else = b.If.clone(), b.Then.clone(), b.Else.clone()
+		} else {
+			s.AllOf = append(s.AllOf, &Schema{If: b.If.clone(), Then: b.Then.clone(), Else: b.Else.clone()})
+		}
+	}
+	if len(s.AllOf) == 0 {
+		s.AllOf = nil
+	}
+}
+
+// checkRange reports an error if the lower bound is greater than the upper.
+func (m *merger) checkRange(path, minName, maxName string, lower, upper json.Number) {
+	if lower == "" || upper == "" {
+		return
+	}
+	l, ok1 := new(big.Rat).SetString(lower.String())
+	u, ok2 := new(big.Rat).SetString(upper.String())
+	if ok1 && ok2 && l.Cmp(u) > 0 {
+		m.errorf(path, "%s %s is greater than %s %s", minName, lower, maxName, upper)
+	}
+}
+
+// mergeBound provides the most restrictive of the two bounds.
+func mergeBound(a, b json.Number, lower bool) json.Number {
+	if a == "" {
+		return b
+	}
+	if b == "" {
+		return a
+	}
+	ar, ok1 := new(big.Rat).SetString(a.String())
+	br, ok2 := new(big.Rat).SetString(b.String())
+	if !ok1 || !ok2 {
+		return a
+	}
+	if (br.Cmp(ar) > 0) == lower {
+		return b
+	}
+	return a
+}
+
+func mergeUintBound(a, b *uint64, lower bool) *uint64 {
+	switch {
+	case a == nil && b == nil:
+		return nil
+	case a == nil:
+		v := *b
+		return &v
+	case b == nil:
+		v := *a
+		return &v
+	}
+	v := *a
+	if (*b > *a) == lower {
+		v = *b
+	}
+	return &v
+}
+
+func mergeEqualStrings(a, b string) (string, bool) {
+	if a == "" || a == b {
+		return b, true
+	}
+	if b == "" {
+		return a, true
+	}
+	return a, false
+}
+
+func firstString[S ~string](a, b S) S {
+	if a != "" {
+		return a
+	}
+	return b
+}
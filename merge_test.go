@@ -0,0 +1,263 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mergeTestSchema(t *testing.T, data string) *Schema {
+	t.Helper()
+	s := new(Schema)
+	require.NoError(t, json.Unmarshal([]byte(data), s))
+	return s
+}
+
+func mergeTestJSON(t *testing.T, s *Schema) string {
+	t.Helper()
+	data, err := json.Marshal(s)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     string
+		expected string
+	}{
+		{
+			"bounds",
+			`{"type":"number","minimum":1,"maximum":10}`,
+			`{"type":"integer","minimum":2,"maximum":20,"multipleOf":2}`,
+			`{"type":"integer","multipleOf":2,"maximum":10,"minimum":2}`,
+		},
+		{
+			"type lists",
+			`{"type":["string","integer","null"]}`,
+			`{"type":["number","string"]}`,
+			`{"type":["string","integer"]}`,
+		},
+		{
+			"type list narrowed",
+			`{"type":["number","null"]}`,
+			`{"type":"integer"}`,
+			`{"type":"integer"}`,
+		},
+		{
+			"lengths",
+			`{"minLength":1,"maxLength":10}`,
+			`{"minLength":3}`,
+			`{"maxLength":10,"minLength":3}`,
+		},
+		{
+			"enums",
+			`{"enum":["a","b","c"]}`,
+			`{"enum":["c","b","d"]}`,
+			`{"enum":["b","c"]}`,
+		},
+		{
+			"patterns",
+			`{"pattern":"^a"}`,
+			`{"pattern":"b$","format":"email"}`,
+			`{"allOf":[{"pattern":"b$"}],"pattern":"^a","format":"email"}`,
+		},
+		{
+			"references",
+			`{"$ref":"#/$defs/A"}`,
+			`{"$ref":"#/$defs/B"}`,
+			`{"$ref":"#/$defs/A","allOf":[{"$ref":"#/$defs/B"}]}`,
+		},
+		{
+			"annotations",
+			`{"title":"A","examples":[1]}`,
+			`{"title":"B","description":"B","examples":[2],"readOnly":true}`,
+			`{"title":"A","description":"B","readOnly":true,"examples":[1,2]}`,
+		},
+		{
+			"properties",
+			`{"type":"object","properties":{"a":{"type":"string","maxLength":5},"b":{"type":"integer"}},"required":["a"]}`,
+			`{"type":"object","properties":{"c":{"type":"boolean"},"a":{"minLength":1}},"required":["c","a"]}`,
+			`{"properties":{"a":{"type":"string","maxLength":5,"minLength":1},"b":{"type":"integer"},"c":{"type":"boolean"}},"type":"object","required":["a","c"]}`,
+		},
+		{
+			"additional properties",
+			`{"properties":{"a":{"type":"string"}},"additionalProperties":{"type":"string"}}`,
+			`{"properties":{"b":{"maxLength":3}},"additionalProperties":{"minLength":1}}`,
+			`{"properties":{"a":{"type":"string","minLength":1},"b":{"type":"string","maxLength":3}},"additionalProperties":{"type":"string","minLength":1}}`,
+		},
+		{
+			"optional properties not allowed",
+			`{"properties":{"a":{"type":"string"}},"required":["a"],"additionalProperties":false}`,
+			`{"properties":{"b":{"type":"integer"}}}`,
+			`{"properties":{"a":{"type":"string"},"b":false},"required":["a"],"additionalProperties":false}`,
+		},
+		{
+			"prefix items",
+			`{"prefixItems":[{"type":"string"}],"items":{"type":"integer"}}`,
+			`{"prefixItems":[{"minLength":1},{"minimum":0}],"items":false,"uniqueItems":true}`,
+			`{"prefixItems":[{"type":"string","minLength":1},{"type":"integer","minimum":0}],"items":false,"uniqueItems":true}`,
+		},
+		{
+			"true schema",
+			`true`,
+			`{"type":"string"}`,
+			`{"type":"string"}`,
+		},
+		{
+			"false schema",
+			`{"type":"string"}`,
+			`false`,
+			`false`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := mergeTestSchema(t, tt.a), mergeTestSchema(t, tt.b)
+			s, err := Merge(a, b)
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.expected, mergeTestJSON(t, s))
+			// originals must remain untouched
+			assert.JSONEq(t, tt.a, mergeTestJSON(t, a))
+			assert.JSONEq(t, tt.b, mergeTestJSON(t, b))
+		})
+	}
+}
+
+func TestMergeConflicts(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     string
+		expected string
+	}{
+		{
+			"types",
+			`{"type":"string"}`,
+			`{"type":"integer"}`,
+			`/: type "string" conflicts with "integer"`,
+		},
+		{
+			"type lists",
+			`{"type":["string","null"]}`,
+			`{"type":["integer","boolean"]}`,
+			`/: type ["string" "null"] conflicts with ["integer" "boolean"]`,
+		},
+		{
+			"enums",
+			`{"enum":["a"]}`,
+			`{"enum":["b"]}`,
+			`/: enums have no values in common`,
+		},
+		{
+			"const",
+			`{"const":"a"}`,
+			`{"const":"b"}`,
+			`/: const "a" conflicts with "b"`,
+		},
+		{
+			"range",
+			`{"properties":{"a":{"minimum":10}}}`,
+			`{"properties":{"a":{"maximum":5}}}`,
+			`/properties/a: minimum 10 is greater than maximum 5`,
+		},
+		{
+			"additional properties",
+			`{"properties":{"a":{}},"required":["a"],"additionalProperties":false}`,
+			`{"properties":{"b":{"minItems":3}},"required":["b"],"additionalProperties":false}`,
+			"/properties/a: required property not allowed by additionalProperties\n/properties/b: required property not allowed by additionalProperties",
+		},
+		{
+			"additional properties schema",
+			`{"properties":{"a":{"type":"integer"}}}`,
+			`{"additionalProperties":{"type":"string"}}`,
+			`/properties/a: type "integer" conflicts with "string"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Merge(mergeTestSchema(t, tt.a), mergeTestSchema(t, tt.b))
+			assert.Nil(t, s)
+			assert.EqualError(t, err, tt.expected)
+		})
+	}
+}
+
+func TestFlattenAllOf(t *testing.T) {
+	src := `{
+		"$ref": "#/$defs/User",
+		"$defs": {
+			"Base": {
+				"type": "object",
+				"properties": {
+					"id": {"type": "string", "minLength": 1},
+					"created_at": {"type": "string", "format": "date-time"}
+				},
+				"required": ["id"]
+			},
+			"User": {
+				"allOf": [
+					{"$ref": "#/$defs/Base"},
+					{
+						"properties": {
+							"id": {"maxLength": 36},
+							"name": {"type": "string"}
+						},
+						"required": ["name"]
+					}
+				]
+			}
+		}
+	}`
+	s := mergeTestSchema(t, src)
+	f, err := FlattenAllOf(s)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"type": "object",
+		"properties": {
+			"id": {"type": "string", "minLength": 1, "maxLength": 36},
+			"created_at": {"type": "string", "format": "date-time"},
+			"name": {"type": "string"}
+		},
+		"required": ["id", "name"]
+	}`, mergeTestJSON(t, f.Definitions["User"]))
+	assert.JSONEq(t, src, mergeTestJSON(t, s), "original should not be modified")
+}
+
+func TestFlattenAllOfEmbedded(t *testing.T) {
+	r := &Reflector{EmbeddedAsAllOf: true}
+	s := r.Reflect(&EmbeddedOrder{})
+	f, err := FlattenAllOf(s)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"properties": {
+			"id": {"type": "string", "minLength": 1},
+			"created_at": {"type": "string", "format": "date-time"},
+			"name": {"type": "string"}
+		},
+		"unevaluatedProperties": false,
+		"type": "object",
+		"required": ["id", "created_at", "name"]
+	}`, mergeTestJSON(t, f.Definitions["EmbeddedCustomer"]))
+}
+
+func TestFlattenAllOfRecursive(t *testing.T) {
+	s := mergeTestSchema(t, `{
+		"$ref": "#/$defs/Node",
+		"$defs": {
+			"Node": {
+				"allOf": [{"$ref": "#/$defs/Node"}, {"type": "object"}]
+			}
+		}
+	}`)
+	f, err := FlattenAllOf(s)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"type":"object","allOf":[{"$ref":"#/$defs/Node"}]}`, mergeTestJSON(t, f.Definitions["Node"]))
+}
+
+func TestFlattenAllOfConflict(t *testing.T) {
+	s := mergeTestSchema(t, `{"properties":{"a":{"allOf":[{"type":"string"},{"type":"boolean"}]}}}`)
+	_, err := FlattenAllOf(s)
+	assert.EqualError(t, err, `/properties/a: type "string" conflicts with "boolean"`)
+}
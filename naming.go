@@ -0,0 +1,282 @@
+package jsonschema
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var matchMajorVersion = regexp.MustCompile(`^v[0-9]+$`)
+
+// NameConflictError describes two different types that were assigned the same
+// definition name.
+type NameConflictError struct {
+	Name     string
+	Type     reflect.Type
+	Existing reflect.Type
+}
+
+// Error provides a description of the conflict.
+func (e *NameConflictError) Error() string {
+	return fmt.Sprintf("definition name %q of %s already used by %s", e.Name, fullyQualifiedTypeName(e.Type), fullyQualifiedTypeName(e.Existing))
+}
+
+// ErrorOnNameConflict can be assigned to the Reflector's ResolveNameConflict
+// property in order to report a *NameConflictError from the TryReflect
+// methods if two types would share the same definition name.
+func ErrorOnNameConflict(name string, t, existing reflect.Type) (string, error) {
+	return "", &NameConflictError{Name: name, Type: t, Existing: existing}
+}
+
+// addConflict records that the type was given a name already assigned to a
+// different type.
+func (s *reflectState) addConflict(name string, t reflect.Type) {
+	for _, c := range s.conflicts[name] {
+		if c == t {
+			return
+		}
+	}
+	s.conflicts[name] = append(s.conflicts[name], t)
+}
+
+// resolveNameConflicts provides new names for all the types that share a
+// name with another, using the ResolveNameConflict function or qualifying
+// them with their package paths. Types are considered in the order of their
+// package paths, so the results do not depend on the order they were found
+// in, and names already in use are given a numeric suffix.
+func (r *Reflector) resolveNameConflicts() (map[reflect.Type]string, []error) {
+	conflicting := make(map[reflect.Type]bool)
+	groups := make(map[string][]reflect.Type, len(r.state.conflicts))
+	for name, types := range r.state.conflicts {
+		group := append([]reflect.Type{r.state.types[name]}, types...)
+		sort.Slice(group, func(i, j int) bool {
+			return fullyQualifiedTypeName(group[i]) < fullyQualifiedTypeName(group[j])
+		})
+		for _, t := range group {
+			conflicting[t] = true
+		}
+		groups[name] = group
+	}
+	taken := make(map[string]bool)
+	for name, t := range r.state.types {
+		if !conflicting[t] {
+			taken[name] = true
+		}
+	}
+
+	var errs []error
+	names := make(map[reflect.Type]string, len(conflicting))
+	for _, name := range sortedKeys(groups) {
+		group := groups[name]
+		alts := qualifiedTypeNames(name, group)
+		rejected := false
+		for i, t := range group {
+			if r.ResolveNameConflict != nil && !rejected {
+				existing := group[0]
+				if i == 0 {
+					existing = group[1]
+				}
+				alt, err := r.ResolveNameConflict(name, t, existing)
+				if err != nil {
+					errs = append(errs, err)
+					rejected = true
+				} else if alt != "" {
+					alts[i] = alt
+				}
+			}
+		}
+		for i, t := range group {
+			alt := alts[i]
+			for n := 2; taken[alt]; n++ {
+				alt = alts[i] + strconv.Itoa(n)
+			}
+			taken[alt] = true
+			names[t] = alt
+		}
+	}
+	return names, errs
+}
+
+// qualifiedTypeNames provides the default names for the types that share the
+// name, prefixed with as many elements of their package paths as needed to
+// tell them apart. Instantiated generic types of the same package have their
+// type arguments qualified instead.
+func qualifiedTypeNames(name string, group []reflect.Type) []string {
+	samePackage := true
+	for _, t := range group[1:] {
+		if t.PkgPath() != group[0].PkgPath() {
+			samePackage = false
+		}
+	}
+	var prev []string
+	for depth := 1; ; depth++ {
+		names := make([]string, len(group))
+		seen := make(map[string]bool, len(group))
+		unique := true
+		for i, t := range group {
+			n := name
+			if genericTypeName(t) == name {
+				n = qualifiedGenericTypeName(t, depth)
+			}
+			if !samePackage {
+				n = packagePathName(t.PkgPath(), depth) + n
+			}
+			unique = unique && !seen[n]
+			seen[n] = true
+			names[i] = n
+		}
+		if unique || reflect.DeepEqual(names, prev) {
+			return names
+		}
+		prev = names
+	}
+}
+
+// genericTypeName converts the name of an instantiated generic type such as
+// `Page[github.com/acme/models.User]` into a name that can be safely used in
+// JSON pointers and URIs, like `PageOfModelsUser`. Type arguments defined in a
+// package other than the generic type's own are prefixed with their package
+// name to avoid collisions. Names of non-generic types are returned as is.
+func genericTypeName(t reflect.Type) string {
+	return qualifiedGenericTypeName(t, 1)
+}
+
+// qualifiedGenericTypeName behaves like genericTypeName, but prefixes type
+// arguments with the last depth elements of their package path, so that
+// arguments from packages with the same name can be told apart.
+func qualifiedGenericTypeName(t reflect.Type, depth int) string {
+	name := t.Name()
+	i := strings.IndexByte(name, '[')
+	if i < 0 || !strings.HasSuffix(name, "]") {
+		return name
+	}
+	return name[:i] + typeArgsName(name[i+1:len(name)-1], t.PkgPath(), depth)
+}
+
+// typeArgsName provides the name suffix for the comma separated list of type
+// arguments.
+func typeArgsName(args, pkg string, depth int) string {
+	parts := splitTypeArgs(args)
+	for i, a := range parts {
+		parts[i] = typeArgName(a, pkg, depth)
+	}
+	return "Of" + strings.Join(parts, "And")
+}
+
+// typeArgName converts a single type argument, as output by the reflect
+// package, into a camel case name. Pointers and array lengths are included,
+// so that different type arguments are not given the same name.
+func typeArgName(s, pkg string, depth int) string {
+	s = strings.TrimSpace(s)
+	switch {
+	case strings.HasPrefix(s, "*"):
+		return "PtrTo" + typeArgName(s[1:], pkg, depth)
+	case strings.HasPrefix(s, "[]"):
+		return "SliceOf" + typeArgName(s[2:], pkg, depth)
+	case strings.HasPrefix(s, "["):
+		if i := strings.IndexByte(s, ']'); i > 0 {
+			return "ArrayOf" + s[1:i] + typeArgName(s[i+1:], pkg, depth)
+		}
+	case strings.HasPrefix(s, "map["):
+		if i := closingBracket(s, 3); i > 0 {
+			return "MapOf" + typeArgName(s[4:i], pkg, depth) + "And" + typeArgName(s[i+1:], pkg, depth)
+		}
+	case s == "interface {}":
+		return "Any"
+	}
+
+	base, args := s, ""
+	if i := strings.IndexByte(s, '['); i > 0 && strings.HasSuffix(s, "]") {
+		base, args = s[:i], s[i+1:len(s)-1]
+	}
+	var qualifier string
+	if i := strings.LastIndexByte(base, '.'); i >= 0 {
+		if base[:i] != pkg {
+			qualifier = packagePathName(base[:i], depth)
+		}
+		base = base[i+1:]
+	}
+	name := qualifier + camelCase(base)
+	if args != "" {
+		name += typeArgsName(args, pkg, depth)
+	}
+	return name
+}
+
+// packageName provides a camel case name for the package path, ignoring
+// any trailing major version element.
+func packageName(path string) string {
+	return packagePathName(path, 1)
+}
+
+// packagePathName provides a camel case name from the last depth elements of
+// the package path, ignoring any trailing major version element.
+func packagePathName(path string, depth int) string {
+	parts := strings.Split(path, "/")
+	if len(parts) > 1 && matchMajorVersion.MatchString(parts[len(parts)-1]) {
+		parts = parts[:len(parts)-1]
+	}
+	if depth < len(parts) {
+		parts = parts[len(parts)-depth:]
+	}
+	return camelCase(strings.Join(parts, "/"))
+}
+
+// camelCase removes any characters that are not letters or digits from the
+// string, capitalizing the first letter of each remaining word.
+func camelCase(s string) string {
+	words := strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var b strings.Builder
+	for _, w := range words {
+		r, size := utf8.DecodeRuneInString(w)
+		b.WriteRune(unicode.ToUpper(r))
+		b.WriteString(w[size:])
+	}
+	return b.String()
+}
+
+// splitTypeArgs splits a list of type arguments on the commas that are not
+// nested inside brackets, parenthesis, or braces.
+func splitTypeArgs(s string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '[', '(', '{':
+			depth++
+		case ']', ')', '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// closingBracket provides the index of the bracket that closes the one
+// opened at the provided position, or -1 if not found.
+func closingBracket(s string, open int) int {
+	depth := 0
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
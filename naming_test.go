@@ -0,0 +1,214 @@
+package jsonschema
+
+import (
+	"reflect"
+	"testing"
+
+	altnested "github.com/invopop/jsonschema/examples/alt/nested"
+	"github.com/invopop/jsonschema/examples/nested"
+	"github.com/stretchr/testify/assert"
+)
+
+type GenericPage[T any] struct {
+	Items []T    `json:"items"`
+	Next  string `json:"next,omitempty"`
+}
+
+type GenericPair[K comparable, V any] struct {
+	Key   K `json:"key"`
+	Value V `json:"value"`
+}
+
+type GenericPages struct {
+	Pets   GenericPage[nested.Pet] `json:"pets"`
+	Inners GenericPage[Inner]      `json:"inners"`
+	Names  GenericPage[string]     `json:"names"`
+}
+
+func TestGenericTypeName(t *testing.T) {
+	tests := []struct {
+		typ      reflect.Type
+		expected string
+	}{
+		{reflect.TypeOf(Inner{}), "Inner"},
+		{reflect.TypeOf(GenericPage[string]{}), "GenericPageOfString"},
+		{reflect.TypeOf(GenericPage[Inner]{}), "GenericPageOfInner"},
+		{reflect.TypeOf(GenericPage[nested.Pet]{}), "GenericPageOfNestedPet"},
+		{reflect.TypeOf(GenericPage[*nested.Pet]{}), "GenericPageOfPtrToNestedPet"},
+		{reflect.TypeOf(GenericPage[[]int]{}), "GenericPageOfSliceOfInt"},
+		{reflect.TypeOf(GenericPage[[2]int]{}), "GenericPageOfArrayOf2Int"},
+		{reflect.TypeOf(GenericPage[[3]*int]{}), "GenericPageOfArrayOf3PtrToInt"},
+		{reflect.TypeOf(GenericPage[map[string]nested.Pet]{}), "GenericPageOfMapOfStringAndNestedPet"},
+		{reflect.TypeOf(GenericPage[any]{}), "GenericPageOfAny"},
+		{reflect.TypeOf(GenericPair[string, int]{}), "GenericPairOfStringAndInt"},
+		{reflect.TypeOf(GenericPage[GenericPair[string, nested.Pet]]{}), "GenericPageOfGenericPairOfStringAndNestedPet"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			assert.Equal(t, tt.expected, genericTypeName(tt.typ))
+		})
+	}
+}
+
+type GenericPageCollisions struct {
+	Ints      GenericPage[int]    `json:"ints"`
+	IntPtrs   GenericPage[*int]   `json:"int_ptrs"`
+	Pairs     GenericPage[[2]int] `json:"pairs"`
+	Triplets  GenericPage[[3]int] `json:"triplets"`
+	Quantity  GenericPage[[]int]  `json:"quantity"`
+	Recursive GenericPage[**int]  `json:"recursive"`
+}
+
+func TestGenericTypeNameCollisions(t *testing.T) {
+	r := &Reflector{}
+	s := r.Reflect(&GenericPageCollisions{})
+	for _, name := range []string{
+		"GenericPageOfInt",
+		"GenericPageOfPtrToInt",
+		"GenericPageOfArrayOf2Int",
+		"GenericPageOfArrayOf3Int",
+		"GenericPageOfSliceOfInt",
+		"GenericPageOfPtrToPtrToInt",
+	} {
+		assert.Contains(t, s.Definitions, name)
+	}
+	assert.Len(t, s.Definitions, 7)
+}
+
+type GenericPagePackages struct {
+	Pets    GenericPage[nested.Pet]    `json:"pets"`
+	AltPets GenericPage[altnested.Pet] `json:"alt_pets"`
+	Pet     nested.Pet                 `json:"pet"`
+	AltPet  altnested.Pet              `json:"alt_pet"`
+}
+
+func TestGenericTypePackageCollisions(t *testing.T) {
+	r := &Reflector{}
+	s := r.Reflect(&GenericPagePackages{})
+	for _, name := range []string{
+		"GenericPageOfExamplesNestedPet",
+		"GenericPageOfAltNestedPet",
+		"ExamplesNestedPet",
+		"AltNestedPet",
+	} {
+		assert.Contains(t, s.Definitions, name)
+	}
+	assert.Len(t, s.Definitions, 5)
+	p, _ := s.Definitions["GenericPageOfAltNestedPet"].Properties.Get("items")
+	assert.Equal(t, "#/$defs/AltNestedPet", p.Items.Ref)
+}
+
+func TestPackageName(t *testing.T) {
+	assert.Equal(t, "Models", packageName("github.com/acme/models"))
+	assert.Equal(t, "Jsonschema", packageName("github.com/invopop/jsonschema/v2"))
+	assert.Equal(t, "YamlV3", packageName("gopkg.in/yaml.v3"))
+	assert.Equal(t, "GoYaml", packageName("github.com/acme/go-yaml"))
+	assert.Equal(t, "AcmeModels", packagePathName("github.com/acme/models", 2))
+	assert.Equal(t, "InvopopJsonschema", packagePathName("github.com/invopop/jsonschema/v2", 2))
+	assert.Equal(t, "GithubComAcmeModels", packagePathName("github.com/acme/models", 5))
+}
+
+func TestGenericTypeNaming(t *testing.T) {
+	r := &Reflector{}
+	compareSchemaOutput(t, "fixtures/generic_types.json", r, &GenericPages{})
+
+	r = &Reflector{
+		Namer: func(t reflect.Type) string {
+			if t == reflect.TypeOf(GenericPage[string]{}) {
+				return "StringPage"
+			}
+			return ""
+		},
+	}
+	s := r.Reflect(&GenericPages{})
+	assert.Contains(t, s.Definitions, "StringPage")
+	assert.Contains(t, s.Definitions, "GenericPageOfInner")
+	assert.NotContains(t, s.Definitions, "GenericPageOfString")
+}
+
+func TestGenericTypeRootID(t *testing.T) {
+	r := &Reflector{}
+	s := r.Reflect(&GenericPage[nested.Pet]{})
+	assert.EqualValues(t, "https://github.com/invopop/jsonschema/generic-page-of-nested-pet", s.ID)
+	assert.Equal(t, "#/$defs/GenericPageOfNestedPet", s.Ref)
+}
+
+type Pet struct {
+	Species string `json:"species"`
+}
+
+type PetOwner struct {
+	Pet       Pet         `json:"pet"`
+	NestedPet nested.Pet  `json:"nested_pet"`
+	OtherPet  *nested.Pet `json:"other_pet,omitempty"`
+}
+
+type PetOwnerReversed struct {
+	NestedPet nested.Pet `json:"nested_pet"`
+	Pet       Pet        `json:"pet"`
+}
+
+func TestDefinitionNameConflicts(t *testing.T) {
+	r := &Reflector{}
+	compareSchemaOutput(t, "fixtures/name_conflicts.json", r, &PetOwner{})
+
+	s := r.Reflect(&PetOwnerReversed{})
+	assert.Contains(t, s.Definitions, "JsonschemaPet")
+	assert.Contains(t, s.Definitions, "NestedPet")
+	assert.NotContains(t, s.Definitions, "Pet")
+
+	r = &Reflector{
+		ResolveNameConflict: func(name string, t, _ reflect.Type) (string, error) {
+			return name + "From" + packageName(t.PkgPath()), nil
+		},
+	}
+	s = r.Reflect(&PetOwner{})
+	assert.Contains(t, s.Definitions, "PetFromJsonschema")
+	assert.Contains(t, s.Definitions, "PetFromNested")
+	p, _ := s.Definitions["PetOwner"].Properties.Get("other_pet")
+	assert.Equal(t, "#/$defs/PetFromNested", p.Ref)
+}
+
+func TestDefinitionNameConflictErrors(t *testing.T) {
+	r := &Reflector{ResolveNameConflict: ErrorOnNameConflict}
+	s, err := r.TryReflect(&PetOwner{})
+	assert.Nil(t, s)
+	assert.EqualError(t, err, `definition name "Pet" of github.com/invopop/jsonschema.Pet already used by github.com/invopop/jsonschema/examples/nested.Pet`)
+	var conflict *NameConflictError
+	if assert.ErrorAs(t, err, &conflict) {
+		assert.Equal(t, "Pet", conflict.Name)
+		assert.Equal(t, reflect.TypeOf(Pet{}), conflict.Type)
+		assert.Equal(t, reflect.TypeOf(nested.Pet{}), conflict.Existing)
+	}
+
+	s, refs, err := r.TryReflectMany(&Pet{}, &nested.Pet{})
+	assert.Nil(t, s)
+	assert.Nil(t, refs)
+	assert.ErrorAs(t, err, &conflict)
+
+	// the default names are used when errors cannot be returned
+	s = r.Reflect(&PetOwner{})
+	assert.Contains(t, s.Definitions, "JsonschemaPet")
+	assert.Contains(t, s.Definitions, "NestedPet")
+
+	r = &Reflector{}
+	s, err = r.TryReflect(&PetOwner{})
+	assert.NoError(t, err)
+	assert.Contains(t, s.Definitions, "NestedPet")
+	s, refs, err = r.TryReflectMany(&Pet{}, &nested.Pet{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"#/$defs/JsonschemaPet", "#/$defs/NestedPet"}, refs)
+	assert.Len(t, s.Definitions, 2)
+}
+
+func TestDefinitionNameConflictSuffix(t *testing.T) {
+	r := &Reflector{
+		ResolveNameConflict: func(_ string, _, _ reflect.Type) (string, error) {
+			return "PetOwner", nil
+		},
+	}
+	s := r.Reflect(&PetOwner{})
+	assert.Contains(t, s.Definitions, "PetOwner2")
+	assert.Contains(t, s.Definitions, "PetOwner3")
+	assert.Len(t, s.Definitions, 3)
+}
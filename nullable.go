@@ -0,0 +1,66 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// NullableStyle defines how a schema that also allows null values is
+// represented.
+type NullableStyle int
+
+const (
+	// NullableOneOf wraps the schema in a `oneOf` list alongside a schema
+	// with the "null" type.
+	NullableOneOf NullableStyle = iota
+	// NullableAnyOf wraps the schema in an `anyOf` list alongside a schema
+	// with the "null" type.
+	NullableAnyOf
+	// NullableTypeArray adds "null" to the schema's types, like
+	// `"type": ["string", "null"]`, which is preferred by many tools. Schemas
+	// without a type, like references, will use an `anyOf` list instead.
+	NullableTypeArray
+)
+
+var jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
+// nullableFromType determines if the field's type will be encoded as null
+// when empty, instead of being omitted. With JSONv2, nil slices and maps are
+// encoded as empty unless the `format:emitnull` option is used.
+func (r *Reflector) nullableFromType(t reflect.Type, jsonTags []string) bool {
+	if jsonTagHasOption(jsonTags, "omitempty") || jsonTagHasOption(jsonTags, "omitzero") {
+		return false
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		return true
+	case reflect.Slice, reflect.Map:
+		if r.JSONv2 && jsonTagFormat(jsonTags) != "emitnull" {
+			return false
+		}
+		// custom encodings may not use null
+		return !t.Implements(jsonMarshalerType)
+	}
+	return false
+}
+
+// nullableSchema provides a schema that matches the same values as the one
+// provided, or null, in the style defined by the NullableAs option.
+func (r *Reflector) nullableSchema(s *Schema) *Schema {
+	null := &Schema{Type: "null"}
+	switch r.NullableAs {
+	case NullableTypeArray:
+		if types := s.typeList(); len(types) > 0 && s.Ref == "" && s.Const == nil {
+			s.setTypes(appendUniqueString(append([]string{}, types...), "null"))
+			if s.Enum != nil {
+				s.Enum = append(append([]any{}, s.Enum...), nil)
+			}
+			return s
+		}
+		fallthrough
+	case NullableAnyOf:
+		return &Schema{AnyOf: []*Schema{s, null}}
+	default:
+		return &Schema{OneOf: []*Schema{s, null}}
+	}
+}
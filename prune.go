@@ -0,0 +1,149 @@
+package jsonschema
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// UnresolvedRef describes a reference that does not point to any location
+// in the schema document it belongs to.
+type UnresolvedRef struct {
+	// Path is the JSON Pointer to the schema containing the reference.
+	Path string
+	// Ref is the reference that could not be resolved.
+	Ref string
+}
+
+// Error provides a description of the unresolved reference, so that it may be
+// used as an error.
+func (u *UnresolvedRef) Error() string {
+	path := u.Path
+	if path == "" {
+		path = "/"
+	}
+	return fmt.Sprintf("%s: unresolved reference %q", path, u.Ref)
+}
+
+// Prune removes the root schema's definitions that cannot be reached by
+// following references from the root, and provides the sorted list of names
+// removed. References may use JSON Pointers, like `#/$defs/User`, anchors, or
+// the `$id` of any schema in the document.
+func Prune(s *Schema) []string {
+	if s == nil || len(s.Definitions) == 0 {
+		return nil
+	}
+	idx := newRefIndex(s)
+	reachable := make(map[string]bool)
+	var visit func(ptr string, x *Schema)
+	visit = func(start string, x *Schema) {
+		x.walk(start, func(ptr string, y *Schema) bool {
+			if ptr != start && ptr == "/$defs/"+escapePointer(rootDefinitionName(ptr)) {
+				// definitions are only visited when referenced
+				return false
+			}
+			if y.Ref == "" {
+				return true
+			}
+			target, ok, _ := idx.resolve(y.Ref)
+			name := rootDefinitionName(target)
+			if ok && name != "" && !reachable[name] {
+				reachable[name] = true
+				visit("/$defs/"+escapePointer(name), s.Definitions[name])
+			}
+			return true
+		})
+	}
+	visit("", s)
+
+	var removed []string
+	for name := range s.Definitions {
+		if !reachable[name] {
+			removed = append(removed, name)
+			delete(s.Definitions, name)
+		}
+	}
+	sort.Strings(removed)
+	return removed
+}
+
+// UnresolvedRefs provides the list of references in the schema document that
+// point to a location that does not exist, in the order they are found.
+// References to other documents, whose base URI does not match the `$id` of
+// any schema in the document, are not checked.
+func UnresolvedRefs(s *Schema) []*UnresolvedRef {
+	idx := newRefIndex(s)
+	var list []*UnresolvedRef
+	s.walk("", func(ptr string, x *Schema) bool {
+		if x.Ref == "" {
+			return true
+		}
+		if _, ok, local := idx.resolve(x.Ref); local && !ok {
+			list = append(list, &UnresolvedRef{Path: ptr, Ref: x.Ref})
+		}
+		return true
+	})
+	return list
+}
+
+// refIndex contains the locations of all the schemas in a document, so that
+// references to them can be resolved.
+type refIndex struct {
+	schemas map[string]*Schema
+	anchors map[string]string
+	ids     map[string]string
+}
+
+func newRefIndex(root *Schema) *refIndex {
+	idx := &refIndex{
+		schemas: make(map[string]*Schema),
+		anchors: make(map[string]string),
+		ids:     make(map[string]string),
+	}
+	root.walk("", func(ptr string, x *Schema) bool {
+		idx.schemas[ptr] = x
+		if _, ok := idx.anchors[x.Anchor]; x.Anchor != "" && !ok {
+			idx.anchors[x.Anchor] = ptr
+		}
+		if _, ok := idx.ids[x.ID.String()]; x.ID != EmptyID && !ok {
+			idx.ids[x.ID.String()] = ptr
+		}
+		return true
+	})
+	return idx
+}
+
+// resolve provides the JSON Pointer to the schema the reference points to,
+// if found. Local will be false for references to other documents.
+func (idx *refIndex) resolve(ref string) (ptr string, found, local bool) {
+	base, fragment, _ := strings.Cut(ref, "#")
+	if base != "" {
+		var ok bool
+		if ptr, ok = idx.ids[base]; !ok {
+			return "", false, false
+		}
+	}
+	switch {
+	case fragment == "":
+		// whole document or resource
+	case strings.HasPrefix(fragment, "/"):
+		ptr += fragment
+	default:
+		var ok bool
+		if ptr, ok = idx.anchors[fragment]; !ok {
+			return "", false, true
+		}
+	}
+	_, found = idx.schemas[ptr]
+	return ptr, found, true
+}
+
+// rootDefinitionName provides the name of the root definition that contains
+// the location of the JSON Pointer, if any.
+func rootDefinitionName(ptr string) string {
+	if !strings.HasPrefix(ptr, "/$defs/") {
+		return ""
+	}
+	name, _, _ := strings.Cut(strings.TrimPrefix(ptr, "/$defs/"), "/")
+	return unescapePointer(name)
+}
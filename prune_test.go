@@ -0,0 +1,68 @@
+package jsonschema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrune(t *testing.T) {
+	s := mergeTestSchema(t, `{
+		"$id": "https://example.com/root",
+		"$ref": "#/$defs/Root",
+		"$defs": {
+			"Root": {
+				"properties": {
+					"a": {"$ref": "#/$defs/A"},
+					"b": {"$ref": "#B"},
+					"c": {"$ref": "https://example.com/c"},
+					"d": {"$ref": "#/$defs/D/properties/x"}
+				}
+			},
+			"A": {"items": {"$ref": "#/$defs/A"}},
+			"B": {"$anchor": "B"},
+			"C": {"$id": "https://example.com/c", "$ref": "#/$defs/Nested", "$defs": {"Nested": {}}},
+			"D": {"properties": {"x": {"$ref": "#/$defs/E"}}},
+			"E": {},
+			"Unused": {"$ref": "#/$defs/AlsoUnused"},
+			"AlsoUnused": {}
+		}
+	}`)
+	assert.Equal(t, []string{"AlsoUnused", "Unused"}, Prune(s))
+	assert.Equal(t, []string{"A", "B", "C", "D", "E", "Root"}, sortedKeys(s.Definitions))
+	assert.Empty(t, Prune(s))
+}
+
+func TestPruneReflected(t *testing.T) {
+	s := (&Reflector{}).Reflect(&TestUser{})
+	require.Contains(t, s.Definitions, "GrandfatherType")
+	s.Definitions["TestUser"].Properties.Set("grand", &Schema{Type: "string"})
+	assert.Equal(t, []string{"GrandfatherType"}, Prune(s))
+	assert.Contains(t, s.Definitions, "TestUser")
+}
+
+func TestUnresolvedRefs(t *testing.T) {
+	s := mergeTestSchema(t, `{
+		"$id": "https://example.com/root",
+		"properties": {
+			"a": {"$ref": "#/$defs/A"},
+			"b": {"$ref": "#/$defs/Missing"},
+			"c": {"$ref": "#missing"},
+			"d": {"$ref": "https://example.com/root#/$defs/A/properties/nope"},
+			"e": {"$ref": "https://example.com/other"},
+			"f": {"$ref": "#"}
+		},
+		"$defs": {
+			"A": {"items": {"$ref": "#/$defs/Gone"}}
+		}
+	}`)
+	list := UnresolvedRefs(s)
+	require.Len(t, list, 4)
+	assert.EqualError(t, list[0], `/$defs/A/items: unresolved reference "#/$defs/Gone"`)
+	assert.Equal(t, "/properties/b", list[1].Path)
+	assert.Equal(t, "#missing", list[2].Ref)
+	assert.Equal(t, "/properties/d", list[3].Path)
+
+	assert.Empty(t, UnresolvedRefs((&Reflector{}).Reflect(&TestUser{})))
+}
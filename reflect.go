@@ -8,12 +8,16 @@ package jsonschema
 
 import (
 	"bytes"
+	"encoding"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net"
 	"net/url"
 	"reflect"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 )
 
@@ -70,6 +74,11 @@ func ReflectFromType(t reflect.Type) *Schema {
 }
 
 // A Reflector reflects values into a Schema.
+//
+// Once configured, a Reflector may be shared and used concurrently by multiple
+// goroutines, and from within its own custom methods and options. Enabling
+// CacheSchemas is recommended when the same types are reflected repeatedly.
+// Options must not be changed while in use.
 type Reflector struct {
 	// BaseSchemaID defines the URI that will be used as a base to determine Schema
 	// IDs for models. For example, a base Schema ID of `https://invopop.com/schemas`
@@ -106,6 +115,18 @@ type Reflector struct {
 	// default of requiring any key *not* tagged with `json:,omitempty` or `json:,omitzero`.
 	RequiredFromJSONSchemaTags bool
 
+	// NullableFromType when true will allow fields to be null when their Go
+	// type is encoded as null while empty, as is the case for pointers, slices,
+	// and maps, unless they are omitted instead with the `omitempty` or
+	// `omitzero` options. Otherwise, only fields with the `nullable` jsonschema
+	// tag will be nullable.
+	NullableFromType bool
+
+	// NullableAs defines how schemas that also allow null values are
+	// represented, either with a `oneOf` list, the default, an `anyOf` list,
+	// or by adding "null" to the list of types.
+	NullableAs NullableStyle
+
 	// Do not reference definitions. This will remove the top-level $defs map and
 	// instead cause the entire structure of types to be output in one tree. The
 	// list of type definitions (`$defs`) will not be included.
@@ -115,13 +136,68 @@ type Reflector struct {
 	// root as opposed to a definition with a reference.
 	ExpandedStruct bool
 
+	// EmbeddedAsAllOf when true will reflect anonymous embedded structs as
+	// references to their own definitions in an `allOf` list, followed by a
+	// schema with the embedding struct's own properties, instead of copying
+	// the embedded struct's fields. As properties are spread across several
+	// sub-schemas, `unevaluatedProperties` will be used instead of
+	// `additionalProperties` to reject unknown keys. Embedded structs whose
+	// definitions restrict additional properties, which would reject the
+	// properties of the structs that embed them, are referenced through an
+	// open variant of their definition named with an `Open` suffix. Fields
+	// with the `inline` JSON tag will continue to be copied.
+	EmbeddedAsAllOf bool
+
+	// InlineSingleUse when true will only keep the definitions of types that are
+	// referenced more than once, or recursively, and include the schemas of
+	// types used a single time directly where they are referenced. The
+	// reflected type's own definition is always kept. This sits between the
+	// default behaviour of referencing every named type, and DoNotReference,
+	// producing shorter schemas for simple structures.
+	InlineSingleUse bool
+
+	// AsTuple allows a function to be defined that determines if a struct or fixed
+	// size array type should be reflected as a tuple: an array schema with one
+	// `prefixItems` entry per field or element, in order, and `items` set to false.
+	// This is useful for types with custom JSON marshalling that output arrays.
+	//
+	// Structs may also opt in directly by including a blank field tagged with
+	// `jsonschema:"tuple"`, and array fields with the same tag.
+	AsTuple func(reflect.Type) bool
+
 	// FieldNameTag will change the tag used to get field names. json tags are used by default.
 	FieldNameTag string
 
+	// JSONv2 when true will reflect structs as they would be encoded by
+	// encoding/json/v2 instead of encoding/json. Field names may be quoted,
+	// maps and raw values tagged with `unknown` or `inline` will allow
+	// additional properties, and `format:` options will change the schema of
+	// byte slices, floats, times, and durations. Byte arrays are encoded as
+	// base64, while nil slices and maps are only nullable with
+	// `format:emitnull`.
+	// Case insensitive matching of names cannot be described by the schema.
+	JSONv2 bool
+
+	// ValidateTag defines the name of the tag containing go-playground/validator
+	// rules, usually "validate", to translate into schema keywords. Supported
+	// rules are `required`, `min`, `max`, `len`, `gt`, `gte`, `lt`, `lte`,
+	// `oneof`, `email`, `url`, `uri`, `uuid`, `ip`, `ipv4`, `ipv6`, and
+	// `hostname`, and `dive` to apply the rules that follow it to the items of
+	// slices or values of maps, including `keys` and `endkeys` for map keys.
+	// Keywords defined in jsonschema tags take precedence.
+	ValidateTag string
+
 	// IgnoredTypes defines a slice of types that should be ignored in the schema,
 	// switching to just allowing additional properties instead.
 	IgnoredTypes []any
 
+	// ContentSchemaTypes maps the names used in `contentSchema` tags to values
+	// whose types will be reflected to provide the schema of the document
+	// encoded inside a string property. For example, a field tagged with
+	// `jsonschema:"contentMediaType=application/json,contentSchema=invoice"`
+	// will use the type registered as "invoice". Unknown names are ignored.
+	ContentSchemaTypes map[string]any
+
 	// Lookup allows a function to be defined that will provide a custom mapping of
 	// types to Schema IDs. This allows existing schema documents to be referenced
 	// by their ID instead of being embedded into the current schema definitions.
@@ -132,9 +208,26 @@ type Reflector struct {
 	Mapper func(reflect.Type) *Schema
 
 	// Namer allows customizing of type names. The default is to use the type's name
-	// provided by the reflect package.
+	// provided by the reflect package, except for instantiated generic types whose
+	// type arguments will be converted into a URI safe suffix. For example,
+	// `Page[github.com/acme/models.User]` will be named `PageOfModelsUser`, while
+	// `Page[string]` becomes `PageOfString`. Returning an empty string will cause
+	// the default name to be used.
 	Namer func(reflect.Type) string
 
+	// ResolveNameConflict allows a function to be defined that will provide an
+	// alternative definition name for each of the types that would normally be
+	// given the same name. This will happen for example when both
+	// `billing.Address` and `shipping.Address` are reflected, and is called for
+	// both, with the other type as `existing`. If no function is set, or it
+	// returns an empty string, the types' package names will be used as a
+	// prefix, resulting in `BillingAddress` and `ShippingAddress`. Types found
+	// while reflecting will be reflected twice when there are conflicts.
+	//
+	// Returning an error, as ErrorOnNameConflict does, causes the TryReflect
+	// methods to fail, while the other methods use the default names.
+	ResolveNameConflict func(name string, t, existing reflect.Type) (string, error)
+
 	// KeyNamer allows customizing of key names.
 	// The default is to use the key's name as is, or the json tag if present.
 	// If a json tag is present, KeyNamer will receive the tag's name as an argument, not the original key name.
@@ -168,6 +261,92 @@ type Reflector struct {
 	//
 	// See also: AddGoComments, LookupComment
 	CommentMap map[string]string
+
+	// CacheSchemas when true will store the definition generated for each type,
+	// along with those it references, so that subsequent calls with the same
+	// options will reuse them instead of walking the type again or calling any
+	// custom JSONSchema or JSONSchemaExtend methods. Each Reflector keeps its
+	// own cache, which is not shared with copies, and is dropped whenever an
+	// option changes. Function, map, and slice options are compared by
+	// identity, so use ClearCache after modifying the contents of a map or
+	// slice in place.
+	CacheSchemas bool
+
+	cache atomic.Value // *reflectCache
+	state *reflectState
+}
+
+// reflectState holds the definition names assigned to each type during a
+// single reflection call, and the reverse, used to detect conflicts.
+type reflectState struct {
+	names map[reflect.Type]string
+	types map[string]reflect.Type
+
+	// conflicts lists the other types that were given each name, which are
+	// renamed once reflection is complete.
+	conflicts map[string][]reflect.Type
+
+	// embedded lists the references to the definitions of embedded structs
+	// made with EmbeddedAsAllOf.
+	embedded []embeddedRef
+
+	// errs collects the problems found while reflecting, reported by the
+	// TryReflect methods.
+	errs []error
+
+	// cache provides the definitions stored by previous calls, when
+	// CacheSchemas is set, for the options the call was made with.
+	cache   *reflectCache
+	options reflectOptions
+}
+
+func newReflectState() *reflectState {
+	return &reflectState{
+		names:     make(map[reflect.Type]string),
+		types:     make(map[string]reflect.Type),
+		conflicts: make(map[string][]reflect.Type),
+	}
+}
+
+// addError records a problem found while reflecting.
+func (r *Reflector) addError(err error) {
+	if r.state != nil {
+		r.state.errs = append(r.state.errs, err)
+	}
+}
+
+// call provides a copy of the Reflector with a fresh state for a single
+// reflection call, so that none is shared between calls, including those
+// made from custom methods or options while reflecting.
+func (r *Reflector) call(cache *reflectCache, opts reflectOptions) *Reflector {
+	c := *r
+	c.state = newReflectState()
+	c.state.cache = cache
+	c.state.options = opts
+	return &c
+}
+
+// reflectCall runs the reflection function with a fresh state. If different
+// types were given the same definition name, all of them are renamed and the
+// function is run again, so that the names do not depend on the order in
+// which types were found. Any errors found are returned.
+func (r *Reflector) reflectCall(fn func(c *Reflector)) error {
+	cache, opts := r.definitionCache()
+	c := r.call(cache, opts)
+	fn(c)
+	if len(c.state.conflicts) == 0 {
+		return errors.Join(c.state.errs...)
+	}
+	names, errs := c.resolveNameConflicts()
+	errs = append(c.state.errs, errs...)
+
+	c = r.call(cache, opts)
+	for t, name := range names {
+		c.state.names[t] = name
+		c.state.types[name] = t
+	}
+	fn(c)
+	return errors.Join(errs...)
 }
 
 // Reflect reflects to Schema from a value.
@@ -177,16 +356,52 @@ func (r *Reflector) Reflect(v any) *Schema {
 
 // ReflectFromType generates root schema
 func (r *Reflector) ReflectFromType(t reflect.Type) *Schema {
+	s, _ := r.reflectFromType(t)
+	return s
+}
+
+// TryReflect behaves like Reflect, but reports the problems found while
+// reflecting, like name conflicts rejected by ErrorOnNameConflict, in which
+// case no schema is returned.
+func (r *Reflector) TryReflect(v any) (*Schema, error) {
+	return r.TryReflectFromType(reflect.TypeOf(v))
+}
+
+// TryReflectFromType behaves like ReflectFromType, but reports the problems
+// found while reflecting, in which case no schema is returned.
+func (r *Reflector) TryReflectFromType(t reflect.Type) (*Schema, error) {
+	s, err := r.reflectFromType(t)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (r *Reflector) reflectFromType(t reflect.Type) (*Schema, error) {
 	if t.Kind() == reflect.Ptr {
 		t = t.Elem() // re-assign from pointer
 	}
 
-	name := r.typeName(t)
+	var s *Schema
+	err := r.reflectCall(func(c *Reflector) {
+		s = c.reflectRoot(t)
+	})
+	return s, err
+}
 
+// reflectRoot generates the root schema for the type, which must not be a
+// pointer.
+func (r *Reflector) reflectRoot(t reflect.Type) *Schema {
 	s := new(Schema)
 	definitions := Definitions{}
 	s.Definitions = definitions
-	bs := r.reflectTypeToSchemaWithID(definitions, t)
+	bs := r.cachedDefinition(definitions, t)
+	if bs == nil {
+		bs = r.reflectTypeToSchemaWithID(definitions, t)
+	}
+	r.openEmbeddedDefinitions(definitions)
+	r.storeDefinitions(definitions)
+	name := r.definitionName(t)
 	if r.ExpandedStruct {
 		if def := definitions[name]; def != nil {
 			*s = *def
@@ -200,15 +415,7 @@ func (r *Reflector) ReflectFromType(t reflect.Type) *Schema {
 
 	// Attempt to set the schema ID
 	if !r.Anonymous && s.ID == EmptyID {
-		baseSchemaID := r.BaseSchemaID
-		if baseSchemaID == EmptyID {
-			id := ID("https://" + t.PkgPath())
-			if err := id.Validate(); err == nil {
-				// it's okay to silently ignore URL errors
-				baseSchemaID = id
-			}
-		}
-		if baseSchemaID != EmptyID {
+		if baseSchemaID := r.baseSchemaID(t); baseSchemaID != EmptyID {
 			s.ID = baseSchemaID.Add(ToSnakeCase(name))
 		}
 	}
@@ -216,11 +423,126 @@ func (r *Reflector) ReflectFromType(t reflect.Type) *Schema {
 	s.Version = Version
 	if !r.DoNotReference {
 		s.Definitions = definitions
+		if r.InlineSingleUse {
+			inlineSingleUseDefinitions(s, nil)
+		}
 	}
 
 	return s
 }
 
+// ReflectMany reflects multiple values into a single root Schema whose
+// definitions are shared between them. See ReflectManyFromTypes.
+func (r *Reflector) ReflectMany(vs ...any) (*Schema, []string) {
+	s, refs, _ := r.reflectMany(valueTypes(vs))
+	return s, refs
+}
+
+// TryReflectMany behaves like ReflectMany, but reports the problems found
+// while reflecting, in which case no schema is returned.
+func (r *Reflector) TryReflectMany(vs ...any) (*Schema, []string, error) {
+	return r.TryReflectManyFromTypes(valueTypes(vs)...)
+}
+
+func valueTypes(vs []any) []reflect.Type {
+	types := make([]reflect.Type, len(vs))
+	for i, v := range vs {
+		types[i] = reflect.TypeOf(v)
+	}
+	return types
+}
+
+// ReflectManyFromTypes generates a single root schema for all the provided
+// types, accumulating their definitions and those of any dependencies in the
+// same `$defs` map. The root will reference each of the types in an `anyOf`
+// list, in the order provided, and the reference used for each type is
+// returned alongside. References will be empty for types that are not stored
+// as definitions, such as unnamed types, or when DoNotReference is true. The
+// definitions of the provided types are kept when InlineSingleUse is set. Nil
+// types, like those of nil values, are skipped and given an empty reference.
+//
+// The root schema ID will be set to the BaseSchemaID, or the first type's
+// package path if not defined.
+func (r *Reflector) ReflectManyFromTypes(types ...reflect.Type) (*Schema, []string) {
+	s, refs, _ := r.reflectMany(types)
+	return s, refs
+}
+
+// TryReflectManyFromTypes behaves like ReflectManyFromTypes, but reports the
+// problems found while reflecting, in which case no schema is returned.
+func (r *Reflector) TryReflectManyFromTypes(types ...reflect.Type) (*Schema, []string, error) {
+	s, refs, err := r.reflectMany(types)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s, refs, nil
+}
+
+func (r *Reflector) reflectMany(types []reflect.Type) (*Schema, []string, error) {
+	var s *Schema
+	var refs []string
+	err := r.reflectCall(func(c *Reflector) {
+		s, refs = c.reflectManyRoot(types)
+	})
+	return s, refs, err
+}
+
+func (r *Reflector) reflectManyRoot(types []reflect.Type) (*Schema, []string) {
+	s := &Schema{Version: Version}
+	definitions := Definitions{}
+	refs := make([]string, len(types))
+	var first reflect.Type
+	for i, t := range types {
+		if t == nil {
+			continue
+		}
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if first == nil {
+			first = t
+		}
+		ts := r.refOrReflectTypeToSchema(definitions, t)
+		refs[i] = ts.Ref
+		s.AnyOf = append(s.AnyOf, ts)
+	}
+	r.openEmbeddedDefinitions(definitions)
+	r.storeDefinitions(definitions)
+
+	if !r.Anonymous && first != nil {
+		s.ID = r.baseSchemaID(first)
+	}
+	if !r.DoNotReference {
+		s.Definitions = definitions
+		if r.InlineSingleUse {
+			keep := make([]string, len(refs))
+			for i, ref := range refs {
+				keep[i] = definitionRefName(ref)
+			}
+			inlineSingleUseDefinitions(s, keep)
+		}
+	}
+
+	return s, refs
+}
+
+// baseSchemaID provides the reflector's base schema ID, or one based on the
+// type's package path if not defined.
+func (r *Reflector) baseSchemaID(t reflect.Type) ID {
+	if r.BaseSchemaID != EmptyID {
+		return r.BaseSchemaID
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	id := ID("https://" + t.PkgPath())
+	if err := id.Validate(); err != nil {
+		// it's okay to silently ignore URL errors
+		return EmptyID
+	}
+	return id
+}
+
 // Available Go defined types for JSON Schema Validation.
 // RFC draft-wright-json-schema-validation-00, section 7.3
 var (
@@ -242,6 +564,9 @@ type protoEnum interface {
 
 var protoEnumType = reflect.TypeOf((*protoEnum)(nil)).Elem()
 
+// Map keys that are not strings will be encoded as text when possible
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
 // SetBaseSchemaID is a helper use to be able to set the reflectors base
 // schema ID from a string as opposed to then ID instance.
 func (r *Reflector) SetBaseSchemaID(id string) {
@@ -260,6 +585,9 @@ func (r *Reflector) refOrReflectTypeToSchema(definitions Definitions, t reflect.
 	if def := r.refDefinition(definitions, t); def != nil {
 		return def
 	}
+	if def := r.cachedDefinition(definitions, t); def != nil {
+		return def
+	}
 
 	return r.reflectTypeToSchemaWithID(definitions, t)
 }
@@ -318,6 +646,10 @@ func (r *Reflector) reflectTypeToSchema(definitions Definitions, t reflect.Type)
 	// Defined format types for JSON Schema Validation
 	// RFC draft-wright-json-schema-validation-00, section 7.3
 	// TODO email RFC section 7.3.2, hostname RFC section 7.3.3, uriref RFC section 7.3.7
+	if t.Implements(embeddedType) {
+		r.reflectEmbeddedContent(definitions, t, st)
+		return st
+	}
 	if t == ipType {
 		// TODO differentiate ipv4 and ipv6 RFC section 7.3.4, 7.3.5
 		st.Type = "string"
@@ -409,13 +741,24 @@ func (r *Reflector) reflectSliceOrArray(definitions Definitions, t reflect.Type,
 	}
 
 	if t.Kind() == reflect.Array {
+		if r.AsTuple != nil && r.AsTuple(t) {
+			st.Type = "array"
+			st.PrefixItems = make([]*Schema, t.Len())
+			for i := range st.PrefixItems {
+				st.PrefixItems[i] = r.refOrReflectTypeToSchema(definitions, t.Elem())
+			}
+			st.Items = FalseSchema
+			l := uint64(t.Len())
+			st.MinItems = &l
+			return
+		}
 		l := uint64(t.Len())
 		st.MinItems = &l
 		st.MaxItems = &l
 	}
 	if t.Kind() == reflect.Slice && t.Elem() == byteSliceType.Elem() {
 		st.Type = "string"
-		// NOTE: ContentMediaType is not set here
+		// the media type is unknown, but may be set with a contentMediaType tag
 		st.ContentEncoding = "base64"
 	} else {
 		st.Type = "array"
@@ -431,19 +774,37 @@ func (r *Reflector) reflectMap(definitions Definitions, t reflect.Type, st *Sche
 		st.Description = r.lookupComment(t, "")
 	}
 
-	switch t.Key().Kind() {
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		st.PatternProperties = map[string]*Schema{
-			"^[0-9]+$": r.refOrReflectTypeToSchema(definitions, t.Elem()),
-		}
-		st.AdditionalProperties = FalseSchema
-		return
-	}
+	st.PropertyNames = r.reflectMapKey(definitions, t.Key())
 	if t.Elem().Kind() != reflect.Interface {
 		st.AdditionalProperties = r.refOrReflectTypeToSchema(definitions, t.Elem())
 	}
 }
 
+// reflectMapKey provides the schema that the keys of a map must match once
+// encoded, following the same rules as encoding/json, or nil if any string
+// may be used.
+func (r *Reflector) reflectMapKey(definitions Definitions, t reflect.Type) *Schema {
+	switch t.Kind() {
+	case reflect.String:
+		// named types may provide their own schema, like an enum
+		s := r.refOrReflectTypeToSchema(definitions, t)
+		if s.Ref == "" && reflect.DeepEqual(s, &Schema{Type: "string"}) {
+			return nil
+		}
+		return s
+	}
+	if t.Implements(textMarshalerType) {
+		return nil
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &Schema{Pattern: "^-?[0-9]+$"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return &Schema{Pattern: "^[0-9]+$"}
+	}
+	return nil
+}
+
 // Reflects a struct to a JSON Schema type.
 func (r *Reflector) reflectStruct(definitions Definitions, t reflect.Type, s *Schema) {
 	// Handle special types
@@ -459,12 +820,16 @@ func (r *Reflector) reflectStruct(definitions Definitions, t reflect.Type, s *Sc
 	}
 
 	r.addDefinition(definitions, t, s)
-	s.Type = "object"
-	s.Properties = NewProperties()
 	s.Description = r.lookupComment(t, "")
 	if r.AssignAnchor {
-		s.Anchor = t.Name()
+		s.Anchor = genericTypeName(t)
 	}
+	if r.reflectAsTuple(t) {
+		r.reflectStructTuple(definitions, t, s)
+		return
+	}
+	s.Type = "object"
+	s.Properties = NewProperties()
 	if !r.AllowAdditionalProperties && s.AdditionalProperties == nil {
 		s.AdditionalProperties = FalseSchema
 	}
@@ -479,6 +844,109 @@ func (r *Reflector) reflectStruct(definitions Definitions, t reflect.Type, s *Sc
 	if !ignored {
 		r.reflectStructFields(s, definitions, t)
 	}
+	for i := 0; i < t.NumField(); i++ {
+		// blank fields may be used to define keywords for the struct itself
+		if f := t.Field(i); f.Name == "_" {
+			s.objectKeywords(splitOnUnescapedCommas(f.Tag.Get("jsonschema")))
+		}
+	}
+	if r.EmbeddedAsAllOf && len(s.AllOf) > 0 {
+		reflectStructAllOf(s)
+	}
+	r.reflectConditions(t, s)
+}
+
+// reflectStructAllOf moves the struct's own properties into the allOf list
+// that already contains the references to its embedded structs, and replaces
+// any restriction on additional properties with one on unevaluated properties.
+func reflectStructAllOf(s *Schema) {
+	if s.Properties.Len() > 0 || len(s.Required) > 0 {
+		s.AllOf = append(s.AllOf, &Schema{
+			Properties: s.Properties,
+			Required:   s.Required,
+		})
+	}
+	s.Properties = nil
+	s.Required = nil
+	if s.AdditionalProperties == FalseSchema {
+		s.AdditionalProperties = nil
+		s.UnevaluatedProperties = FalseSchema
+	}
+}
+
+// reflectEmbeddedStruct provides the schema that references the embedded
+// struct type. References to its definition are recorded, so that they can be
+// replaced by an open variant if the definition turns out to restrict
+// additional properties.
+func (r *Reflector) reflectEmbeddedStruct(definitions Definitions, t reflect.Type) *Schema {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	s := r.refOrReflectTypeToSchema(definitions, t)
+	switch {
+	case s.Ref == "":
+		openSchema(s)
+	case r.state != nil && s.Ref == "#/$defs/"+r.definitionName(t):
+		r.state.embedded = append(r.state.embedded, embeddedRef{t: t, s: s})
+	}
+	return s
+}
+
+// embeddedRef is a reference to the definition of an embedded struct.
+type embeddedRef struct {
+	t reflect.Type
+	s *Schema
+}
+
+// openEmbeddedDefinitions replaces the references to embedded struct
+// definitions that restrict additional properties with references to an open
+// variant of the definition, named with an `Open` suffix, which would
+// otherwise reject the properties of the structs embedding them. The original
+// definitions are left untouched.
+func (r *Reflector) openEmbeddedDefinitions(definitions Definitions) {
+	if r.state == nil || len(r.state.embedded) == 0 {
+		return
+	}
+	open := make(map[string]string)
+	for _, e := range r.state.embedded {
+		name := r.definitionName(e.t)
+		def := definitions[name]
+		if def == nil || !closedSchema(def) {
+			continue
+		}
+		openName, ok := open[name]
+		if !ok {
+			openName = name + "Open"
+			for n := 2; definitions[openName] != nil; n++ {
+				openName = name + "Open" + strconv.Itoa(n)
+			}
+			open[name] = openName
+			definitions[openName] = nil // reserved until all references are updated
+		}
+		e.s.Ref = "#/$defs/" + openName
+	}
+	// variants are cloned once all references have been updated, as
+	// definitions may embed other structs themselves
+	for name, openName := range open {
+		def := definitions[name].clone()
+		openSchema(def)
+		definitions[openName] = def
+	}
+}
+
+// closedSchema returns true if the schema rejects unknown properties.
+func closedSchema(s *Schema) bool {
+	return isFalseSchema(s.AdditionalProperties) || isFalseSchema(s.UnevaluatedProperties)
+}
+
+// openSchema removes any restriction on unknown properties from the schema.
+func openSchema(s *Schema) {
+	if isFalseSchema(s.AdditionalProperties) {
+		s.AdditionalProperties = nil
+	}
+	if isFalseSchema(s.UnevaluatedProperties) {
+		s.UnevaluatedProperties = nil
+	}
 }
 
 func (r *Reflector) reflectStructFields(st *Schema, definitions Definitions, t reflect.Type) {
@@ -506,11 +974,17 @@ func (r *Reflector) reflectStructFields(st *Schema, definitions Definitions, t r
 	}
 
 	handleField := func(f reflect.StructField) {
+		if r.JSONv2 && r.reflectJSONv2Fallback(definitions, st, f) {
+			return
+		}
 		name, shouldEmbed, required, nullable := r.reflectFieldName(f)
 		// if anonymous and exported type should be processed recursively
 		// current type should inherit properties of anonymous one
 		if name == "" {
-			if shouldEmbed {
+			switch {
+			case shouldEmbed && r.EmbeddedAsAllOf && f.Anonymous && !r.inlinedByJSONv2Tags(r.splitJSONTag(f.Tag.Get(r.fieldNameTag()))):
+				st.AllOf = append(st.AllOf, r.reflectEmbeddedStruct(definitions, f.Type))
+			case shouldEmbed:
 				r.reflectStructFields(st, definitions, f.Type)
 			}
 			return
@@ -524,8 +998,18 @@ func (r *Reflector) reflectStructFields(st *Schema, definitions Definitions, t r
 		} else {
 			property = r.refOrReflectTypeToSchema(definitions, f.Type)
 		}
+		if r.JSONv2 {
+			if s := r.jsonV2FieldSchema(f, property); s != nil {
+				property = s
+			}
+		}
 
-		property.structKeywordsFromTags(f, st, name)
+		property = property.withKeywords()
+		if r.validateKeywords(property, f) {
+			required = true
+		}
+		r.structKeywordsFromTags(property, f, st, name)
+		r.contentSchemaKeyword(definitions, property, f)
 		if property.Description == "" {
 			property.Description = r.lookupComment(t, f.Name)
 		}
@@ -534,15 +1018,9 @@ func (r *Reflector) reflectStructFields(st *Schema, definitions Definitions, t r
 		}
 
 		if nullable {
-			property = &Schema{
-				OneOf: []*Schema{
-					property,
-					{
-						Type: "null",
-					},
-				},
-			}
+			property = r.nullableSchema(property)
 		}
+		r.jsonValueKeywords(definitions, property, t, f)
 
 		st.Properties.Set(name, property)
 		if required {
@@ -563,6 +1041,61 @@ func (r *Reflector) reflectStructFields(st *Schema, definitions Definitions, t r
 	}
 }
 
+// reflectAsTuple determines if the struct type should be represented as a
+// tuple, either because the Reflector's AsTuple function says so, or because
+// a blank field has been tagged with `jsonschema:"tuple"`.
+func (r *Reflector) reflectAsTuple(t reflect.Type) bool {
+	if r.AsTuple != nil && r.AsTuple(t) {
+		return true
+	}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Name == "_" && tupleFromJSONSchemaTags(strings.Split(f.Tag.Get("jsonschema"), ",")) {
+			return true
+		}
+	}
+	return false
+}
+
+// reflectStructTuple will reflect the struct's fields, in order, as the
+// prefix items of an array with no additional items.
+func (r *Reflector) reflectStructTuple(definitions Definitions, t reflect.Type, st *Schema) {
+	st.Type = "array"
+	var handleFields func(t reflect.Type)
+	handleFields = func(t reflect.Type) {
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name, shouldEmbed, _, nullable := r.reflectFieldName(f)
+			if name == "" {
+				if shouldEmbed {
+					handleFields(f.Type)
+				}
+				continue
+			}
+			item := r.refOrReflectTypeToSchema(definitions, f.Type)
+			item = item.withKeywords()
+			r.validateKeywords(item, f)
+			r.structKeywordsFromTags(item, f, nil, name)
+			r.contentSchemaKeyword(definitions, item, f)
+			if item.Description == "" {
+				item.Description = r.lookupComment(t, f.Name)
+			}
+			if nullable {
+				item = r.nullableSchema(item)
+			}
+			r.jsonValueKeywords(definitions, item, t, f)
+			st.PrefixItems = append(st.PrefixItems, item)
+		}
+	}
+	handleFields(t)
+	st.Items = FalseSchema
+	l := uint64(len(st.PrefixItems))
+	st.MinItems = &l
+}
+
 func appendUniqueString(base []string, value string) []string {
 	for _, v := range base {
 		if v == value {
@@ -574,19 +1107,48 @@ func appendUniqueString(base []string, value string) []string {
 
 // addDefinition will append the provided schema. If needed, an ID and anchor will also be added.
 func (r *Reflector) addDefinition(definitions Definitions, t reflect.Type, s *Schema) {
-	name := r.typeName(t)
+	name := r.definitionName(t)
 	if name == "" {
 		return
 	}
+	if r.state == nil {
+		r.state = &reflectState{
+			names: make(map[reflect.Type]string),
+			types: make(map[string]reflect.Type),
+		}
+	}
+	r.state.names[t] = name
+	r.state.types[name] = t
 	definitions[name] = s
 }
 
+// definitionName provides the name used to store the type's definition. Types
+// given a name already assigned to a different type are recorded as conflicts,
+// and use a provisional name until they are all renamed.
+func (r *Reflector) definitionName(t reflect.Type) string {
+	if r.state == nil {
+		return r.typeName(t)
+	}
+	if name, ok := r.state.names[t]; ok {
+		return name
+	}
+	name := r.typeName(t)
+	if name == "" {
+		return ""
+	}
+	if existing, ok := r.state.types[name]; ok && existing != t {
+		r.state.addConflict(name, t)
+		return fullyQualifiedTypeName(t)
+	}
+	return name
+}
+
 // refDefinition will provide a schema with a reference to an existing definition.
 func (r *Reflector) refDefinition(definitions Definitions, t reflect.Type) *Schema {
 	if r.DoNotReference {
 		return nil
 	}
-	name := r.typeName(t)
+	name := r.definitionName(t)
 	if name == "" {
 		return nil
 	}
@@ -609,25 +1171,34 @@ func (r *Reflector) lookupID(t reflect.Type) ID {
 	return EmptyID
 }
 
-func (t *Schema) structKeywordsFromTags(f reflect.StructField, parent *Schema, propertyName string) {
+func (r *Reflector) structKeywordsFromTags(t *Schema, f reflect.StructField, parent *Schema, propertyName string) {
 	t.Description = f.Tag.Get("jsonschema_description")
 
 	tags := splitOnUnescapedCommas(f.Tag.Get("jsonschema"))
 	tags = t.genericKeywords(tags, parent, propertyName)
+	if hasEnumKeyword(tags) {
+		// enums defined in jsonschema tags replace those of validator rules
+		t.Enum = nil
+		if t.Items != nil && t.keywordType() == "array" {
+			t.Items.Enum = nil
+		}
+	}
+	tags = withoutJSONValues(tags)
 
 	// The encoding/json ",string" option causes integer, float and boolean
-	// fields to be encoded as JSON strings. Override the reflected type
-	// accordingly before running type-specific keyword parsing so the
-	// generated schema matches the on-the-wire representation.
-	jsonTags := strings.Split(f.Tag.Get("json"), ",")
+	// fields to be encoded as JSON strings, or only numbers with JSONv2.
+	// Override the reflected type accordingly before running type-specific
+	// keyword parsing so the generated schema matches the on-the-wire
+	// representation.
+	jsonTags := r.splitJSONTag(f.Tag.Get(r.fieldNameTag()))
 	switch t.Type {
 	case "integer", "number", "boolean":
-		if jsonTagHasOption(jsonTags, "string") {
+		if jsonTagHasOption(jsonTags, "string") && !(r.JSONv2 && t.Type == "boolean") {
 			t.Type = "string"
 		}
 	}
 
-	switch t.Type {
+	switch t.keywordType() {
 	case "string":
 		t.stringKeywords(tags)
 	case "number":
@@ -636,13 +1207,31 @@ func (t *Schema) structKeywordsFromTags(f reflect.StructField, parent *Schema, p
 		t.numericalKeywords(tags)
 	case "array":
 		t.arrayKeywords(tags)
+		if f.Type.Kind() == reflect.Array && tupleFromJSONSchemaTags(strings.Split(f.Tag.Get("jsonschema"), ",")) {
+			t.arrayToTuple()
+		}
 	case "boolean":
 		t.booleanKeywords(tags)
+	case "object", "":
+		t.objectKeywords(tags)
 	}
+	t.valueKeywords(tags)
 	extras := splitOnUnescapedCommas(f.Tag.Get("jsonschema_extras"))
 	t.extraKeywords(extras)
 }
 
+// withKeywords provides a schema that keywords can be added to. Boolean
+// schemas, which may be shared, are replaced by their object equivalent.
+func (t *Schema) withKeywords() *Schema {
+	if t.boolean == nil {
+		return t
+	}
+	if *t.boolean {
+		return &Schema{}
+	}
+	return &Schema{Not: &Schema{}}
+}
+
 // read struct tags for generic keywords
 func (t *Schema) genericKeywords(tags []string, parent *Schema, propertyName string) []string { //nolint:gocyclo
 	unprocessed := make([]string, 0, len(tags))
@@ -650,13 +1239,17 @@ func (t *Schema) genericKeywords(tags []string, parent *Schema, propertyName str
 		nameValue := strings.SplitN(tag, "=", 2)
 		if len(nameValue) == 2 {
 			name, val := nameValue[0], nameValue[1]
+			if parent == nil && (name == "oneof_required" || name == "anyof_required" || name == "dependentRequired") {
+				// keywords of the parent object, which tuples do not have
+				continue
+			}
 			switch name {
 			case "title":
 				t.Title = val
 			case "description":
 				t.Description = val
 			case "type":
-				t.Type = val
+				t.setTypes(strings.Split(val, ";"))
 			case "anchor":
 				t.Anchor = val
 			case "oneof_required":
@@ -689,6 +1282,13 @@ func (t *Schema) genericKeywords(tags []string, parent *Schema, propertyName str
 					parent.AnyOf = append(parent.AnyOf, typeFound)
 				}
 				typeFound.Required = append(typeFound.Required, propertyName)
+			case "dependentRequired":
+				if parent.DependentRequired == nil {
+					parent.DependentRequired = make(map[string][]string)
+				}
+				for _, dep := range strings.Split(val, ";") {
+					parent.DependentRequired[propertyName] = appendUniqueString(parent.DependentRequired[propertyName], dep)
+				}
 			case "oneof_ref":
 				subSchema := t
 				if t.Items != nil {
@@ -708,7 +1308,7 @@ func (t *Schema) genericKeywords(tags []string, parent *Schema, propertyName str
 				if t.OneOf == nil {
 					t.OneOf = make([]*Schema, 0, 1)
 				}
-				t.Type = ""
+				t.setTypes(nil)
 				types := strings.Split(nameValue[1], ";")
 				for _, ty := range types {
 					t.OneOf = append(t.OneOf, &Schema{
@@ -734,21 +1334,290 @@ func (t *Schema) genericKeywords(tags []string, parent *Schema, propertyName str
 				if t.AnyOf == nil {
 					t.AnyOf = make([]*Schema, 0, 1)
 				}
-				t.Type = ""
+				t.setTypes(nil)
 				types := strings.Split(nameValue[1], ";")
 				for _, ty := range types {
 					t.AnyOf = append(t.AnyOf, &Schema{
 						Type: ty,
 					})
 				}
+			case "readOnly":
+				t.ReadOnly, _ = strconv.ParseBool(val)
+			case "writeOnly":
+				t.WriteOnly, _ = strconv.ParseBool(val)
+			case "deprecated":
+				t.Deprecated, _ = strconv.ParseBool(val)
 			default:
 				unprocessed = append(unprocessed, tag)
 			}
+		} else {
+			switch tag {
+			case "readOnly":
+				t.ReadOnly = true
+			case "writeOnly":
+				t.WriteOnly = true
+			case "deprecated":
+				t.Deprecated = true
+			}
 		}
 	}
 	return unprocessed
 }
 
+// read struct tags for keywords with values that may be used with any type,
+// and are not already handled by the type specific keywords.
+func (t *Schema) valueKeywords(tags []string) {
+	for _, tag := range tags {
+		nameValue := strings.SplitN(tag, "=", 2)
+		if len(nameValue) != 2 {
+			continue
+		}
+		name, val := nameValue[0], nameValue[1]
+		switch name {
+		case "const":
+			if v, ok := t.tagValue(val); ok {
+				t.Const = v
+			}
+		case "example":
+			switch t.keywordType() {
+			case "string", "number", "integer", "array":
+				// already handled
+			default:
+				if v, ok := t.tagValue(val); ok {
+					t.Examples = append(t.Examples, v)
+				}
+			}
+		}
+	}
+}
+
+// hasEnumKeyword returns true if any of the tags defines an enum value.
+func hasEnumKeyword(tags []string) bool {
+	for _, tag := range tags {
+		if strings.HasPrefix(tag, "enum=") {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonValuePrefix identifies tag values that should be parsed as JSON.
+const jsonValuePrefix = "json:"
+
+// withoutJSONValues removes the tags with JSON values, so that they are not
+// parsed by the type specific keywords.
+func withoutJSONValues(tags []string) []string {
+	res := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		if _, val, ok := strings.Cut(tag, "="); ok && strings.HasPrefix(val, jsonValuePrefix) {
+			continue
+		}
+		res = append(res, tag)
+	}
+	return res
+}
+
+// jsonValueKeywords sets the keywords whose values are provided as JSON, either
+// with the `json:` prefix in the jsonschema tag, like `default=json:{"a":1}`,
+// or in their own `jsonschema_default`, `jsonschema_const`,
+// `jsonschema_examples`, and `jsonschema_enum` tags, where the last two
+// expect arrays. Values that are not valid JSON or do not match the type of
+// the field's schema are ignored, and reported by the TryReflect methods.
+func (r *Reflector) jsonValueKeywords(definitions Definitions, t *Schema, st reflect.Type, f reflect.StructField) {
+	invalid := func(tag string, err error) {
+		r.addError(fmt.Errorf("%s.%s: %s: %w", fullyQualifiedTypeName(st), f.Name, tag, err))
+	}
+	for _, tag := range splitOnUnescapedCommas(f.Tag.Get("jsonschema")) {
+		name, val, ok := strings.Cut(tag, "=")
+		if !ok || !strings.HasPrefix(val, jsonValuePrefix) {
+			continue
+		}
+		v, err := r.jsonTagValue(definitions, t, strings.TrimPrefix(val, jsonValuePrefix))
+		if err != nil {
+			invalid(tag, err)
+			continue
+		}
+		switch name {
+		case "default":
+			t.Default = v
+		case "const":
+			t.Const = v
+		case "example":
+			t.Examples = append(t.Examples, v)
+		case "enum":
+			t.Enum = append(t.Enum, v)
+		}
+	}
+	if val, ok := f.Tag.Lookup("jsonschema_default"); ok {
+		if v, err := r.jsonTagValue(definitions, t, val); err != nil {
+			invalid("jsonschema_default", err)
+		} else {
+			t.Default = v
+		}
+	}
+	if val, ok := f.Tag.Lookup("jsonschema_const"); ok {
+		if v, err := r.jsonTagValue(definitions, t, val); err != nil {
+			invalid("jsonschema_const", err)
+		} else {
+			t.Const = v
+		}
+	}
+	if val, ok := f.Tag.Lookup("jsonschema_examples"); ok {
+		if list, err := r.jsonTagValues(definitions, t, val); err != nil {
+			invalid("jsonschema_examples", err)
+		} else {
+			t.Examples = list
+		}
+	}
+	if val, ok := f.Tag.Lookup("jsonschema_enum"); ok {
+		if list, err := r.jsonTagValues(definitions, t, val); err != nil {
+			invalid("jsonschema_enum", err)
+		} else {
+			t.Enum = list
+		}
+	}
+}
+
+// errJSONValueMismatch is reported for JSON tag values that do not match the
+// type of the field's schema.
+var errJSONValueMismatch = errors.New("value does not match the field's schema")
+
+// jsonTagValue parses the JSON value, ensuring it matches the schema's type.
+func (r *Reflector) jsonTagValue(definitions Definitions, t *Schema, data string) (any, error) {
+	var v any
+	if err := decodeJSONValue(data, &v); err != nil {
+		return nil, err
+	}
+	if !valueMatchesSchema(definitions, t, v) {
+		return nil, errJSONValueMismatch
+	}
+	if v == nil {
+		// ensure null is output instead of being omitted
+		return json.RawMessage("null"), nil
+	}
+	return v, nil
+}
+
+// jsonTagValues parses the JSON array, ensuring each of its values match the
+// schema's type.
+func (r *Reflector) jsonTagValues(definitions Definitions, t *Schema, data string) ([]any, error) {
+	var list []any
+	if err := decodeJSONValue(data, &list); err != nil {
+		return nil, err
+	}
+	for _, v := range list {
+		if !valueMatchesSchema(definitions, t, v) {
+			return nil, errJSONValueMismatch
+		}
+	}
+	return list, nil
+}
+
+func decodeJSONValue(data string, v any) error {
+	dec := json.NewDecoder(strings.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if dec.More() {
+		return errors.New("unexpected data after value")
+	}
+	return nil
+}
+
+// valueMatchesSchema checks that the decoded JSON value is of the type
+// expected by the schema, following references to definitions, and checking
+// array items and object properties.
+func valueMatchesSchema(definitions Definitions, s *Schema, v any) bool {
+	for i := 0; s != nil && s.Ref != "" && i <= len(definitions); i++ {
+		def, ok := definitions[definitionRefName(s.Ref)]
+		if !ok {
+			return true
+		}
+		s = def
+	}
+	if s == nil || s.boolean != nil {
+		return s == nil || *s.boolean
+	}
+	for _, a := range s.AllOf {
+		if !valueMatchesSchema(definitions, a, v) {
+			return false
+		}
+	}
+	if len(s.OneOf) > 0 || len(s.AnyOf) > 0 {
+		for _, o := range append(append([]*Schema{}, s.OneOf...), s.AnyOf...) {
+			if valueMatchesSchema(definitions, o, v) {
+				return true
+			}
+		}
+		return false
+	}
+	switch val := v.(type) {
+	case nil:
+		return s.allowsType("null")
+	case bool:
+		return s.allowsType("boolean")
+	case string:
+		return s.allowsType("string")
+	case json.Number:
+		if strings.ContainsAny(val.String(), ".eE") {
+			return s.allowsType("number")
+		}
+		return s.allowsType("integer")
+	case []any:
+		if !s.allowsType("array") {
+			return false
+		}
+		for i, item := range val {
+			is := s.Items
+			if i < len(s.PrefixItems) {
+				is = s.PrefixItems[i]
+			}
+			if !valueMatchesSchema(definitions, is, item) {
+				return false
+			}
+		}
+		return true
+	case map[string]any:
+		if !s.allowsType("object") {
+			return false
+		}
+		for k, item := range val {
+			ps := s.AdditionalProperties
+			if s.Properties != nil {
+				if p, ok := s.Properties.Get(k); ok {
+					ps = p
+				}
+			}
+			if !valueMatchesSchema(definitions, ps, item) {
+				return false
+			}
+		}
+		return true
+	}
+	return true
+}
+
+// tagValue parses a value provided in a tag according to the schema's type.
+// Values for schemas without a type, like references, are ignored as their
+// type cannot be known, and should be provided with the `json:` prefix.
+func (t *Schema) tagValue(val string) (any, bool) {
+	if t.Ref != "" {
+		return nil, false
+	}
+	switch t.keywordType() {
+	case "string":
+		return val, true
+	case "number", "integer":
+		return toJSONNumber(val)
+	case "boolean":
+		b, err := strconv.ParseBool(val)
+		return b, err == nil
+	default:
+		return nil, false
+	}
+}
+
 // read struct tags for boolean type keywords
 func (t *Schema) booleanKeywords(tags []string) {
 	for _, tag := range tags {
@@ -783,12 +1652,10 @@ func (t *Schema) stringKeywords(tags []string) {
 				t.Pattern = val
 			case "format":
 				t.Format = val
-			case "readOnly":
-				i, _ := strconv.ParseBool(val)
-				t.ReadOnly = i
-			case "writeOnly":
-				i, _ := strconv.ParseBool(val)
-				t.WriteOnly = i
+			case "contentMediaType":
+				t.ContentMediaType = val
+			case "contentEncoding":
+				t.ContentEncoding = val
 			case "default":
 				t.Default = val
 			case "example":
@@ -835,20 +1702,76 @@ func (t *Schema) numericalKeywords(tags []string) {
 }
 
 // read struct tags for object type keywords
-// func (t *Type) objectKeywords(tags []string) {
-//     for _, tag := range tags{
-//         nameValue := strings.Split(tag, "=")
-//         name, val := nameValue[0], nameValue[1]
-//         switch name{
-//             case "dependencies":
-//                 t.Dependencies = val
-//                 break;
-//             case "patternProperties":
-//                 t.PatternProperties = val
-//                 break;
-//         }
-//     }
-// }
+func (t *Schema) objectKeywords(tags []string) {
+	for _, tag := range tags {
+		nameValue := strings.SplitN(tag, "=", 2)
+		if len(nameValue) != 2 {
+			continue
+		}
+		name, val := nameValue[0], nameValue[1]
+		switch name {
+		case "minProperties":
+			t.MinProperties = parseUint(val)
+		case "maxProperties":
+			t.MaxProperties = parseUint(val)
+		case "propertyNames":
+			t.propertyNamesKeyword(val)
+		case "patternProperties":
+			t.patternPropertiesKeyword(val)
+		}
+	}
+}
+
+// propertyNamesKeyword adds the pattern to those that property names, or map
+// keys, must match, keeping any other restrictions already defined.
+func (t *Schema) propertyNamesKeyword(pattern string) {
+	pn := t.PropertyNames
+	switch {
+	case pn == nil || pn.boolean != nil:
+		t.PropertyNames = &Schema{Pattern: pattern}
+		return
+	case pn.Pattern == "":
+		c := *pn
+		c.Pattern = pattern
+		t.PropertyNames = &c
+		return
+	case pn.Pattern == pattern:
+		return
+	}
+	for _, s := range pn.AllOf {
+		if s.Pattern == pattern {
+			return
+		}
+	}
+	c := *pn
+	c.AllOf = append(append([]*Schema{}, pn.AllOf...), &Schema{Pattern: pattern})
+	t.PropertyNames = &c
+}
+
+// patternPropertiesKeyword restricts the keys of a map to those that match
+// the pattern, or any other pattern already defined, by moving the schema of
+// the map's values into the pattern properties.
+func (t *Schema) patternPropertiesKeyword(pattern string) {
+	if t.Type != "object" || t.Ref != "" || t.Properties != nil {
+		// only supported for maps
+		return
+	}
+	value := t.AdditionalProperties
+	switch {
+	case value == nil:
+		value = TrueSchema
+	case isFalseSchema(value):
+		value = TrueSchema
+		if keys := sortedKeys(t.PatternProperties); len(keys) > 0 {
+			value = t.PatternProperties[keys[0]]
+		}
+	}
+	if t.PatternProperties == nil {
+		t.PatternProperties = make(map[string]*Schema)
+	}
+	t.PatternProperties[pattern] = value
+	t.AdditionalProperties = FalseSchema
+}
 
 // read struct tags for array type keywords
 func (t *Schema) arrayKeywords(tags []string) {
@@ -886,7 +1809,7 @@ func (t *Schema) arrayKeywords(tags []string) {
 		return
 	}
 
-	switch t.Items.Type {
+	switch t.Items.keywordType() {
 	case "string":
 		t.Items.stringKeywords(unprocessed)
 	case "number":
@@ -900,6 +1823,21 @@ func (t *Schema) arrayKeywords(tags []string) {
 	}
 }
 
+// arrayToTuple converts a fixed length array schema into a tuple with
+// a copy of the items schema for each position.
+func (t *Schema) arrayToTuple() {
+	if t.Items == nil || t.MaxItems == nil {
+		return
+	}
+	t.PrefixItems = make([]*Schema, *t.MaxItems)
+	for i := range t.PrefixItems {
+		item := *t.Items
+		t.PrefixItems[i] = &item
+	}
+	t.Items = FalseSchema
+	t.MaxItems = nil
+}
+
 func (t *Schema) extraKeywords(tags []string) {
 	for _, tag := range tags {
 		nameValue := strings.SplitN(tag, "=", 2)
@@ -980,6 +1918,18 @@ func nullableFromJSONSchemaTags(tags []string) bool {
 	return false
 }
 
+func tupleFromJSONSchemaTags(tags []string) bool {
+	if ignoredByJSONSchemaTags(tags) {
+		return false
+	}
+	for _, tag := range tags {
+		if tag == "tuple" {
+			return true
+		}
+	}
+	return false
+}
+
 func ignoredByJSONTags(tags []string) bool {
 	return tags[0] == "-"
 }
@@ -1039,7 +1989,7 @@ func (r *Reflector) fieldNameTag() string {
 
 func (r *Reflector) reflectFieldName(f reflect.StructField) (string, bool, bool, bool) {
 	jsonTagString := f.Tag.Get(r.fieldNameTag())
-	jsonTags := strings.Split(jsonTagString, ",")
+	jsonTags := r.splitJSONTag(jsonTagString)
 
 	if ignoredByJSONTags(jsonTags) {
 		return "", false, false, false
@@ -1053,10 +2003,17 @@ func (r *Reflector) reflectFieldName(f reflect.StructField) (string, bool, bool,
 	var required bool
 	if !r.RequiredFromJSONSchemaTags {
 		requiredFromJSONTags(jsonTags, &required)
+		if r.JSONv2 && !required && !jsonTagHasOption(jsonTags, "omitzero") && neverEmptyJSONv2(f.Type) {
+			// omitempty has no effect
+			required = true
+		}
 	}
 	requiredFromJSONSchemaTags(schemaTags, &required)
 
 	nullable := nullableFromJSONSchemaTags(schemaTags)
+	if r.NullableFromType && !nullable {
+		nullable = r.nullableFromType(f.Type, jsonTags)
+	}
 
 	if f.Anonymous && jsonTags[0] == "" {
 		// As per JSON Marshal rules, anonymous structs are inherited
@@ -1071,7 +2028,7 @@ func (r *Reflector) reflectFieldName(f reflect.StructField) (string, bool, bool,
 	}
 
 	// As per JSON Marshal rules, inline nested structs that have `inline` tag.
-	if inlinedByJSONTags(jsonTags) {
+	if r.inlinedByJSONv2Tags(jsonTags) {
 		return "", true, false, false
 	}
 
@@ -1102,10 +2059,20 @@ func (t *Schema) UnmarshalJSON(data []byte) error {
 	type SchemaAlt Schema
 	aux := &struct {
 		*SchemaAlt
+		Type json.RawMessage `json:"type,omitempty"`
 	}{
 		SchemaAlt: (*SchemaAlt)(t),
 	}
-	return json.Unmarshal(data, aux)
+	if err := json.Unmarshal(data, aux); err != nil {
+		return err
+	}
+	if len(aux.Type) > 0 && aux.Type[0] == '[' {
+		return json.Unmarshal(aux.Type, &t.Types)
+	}
+	if len(aux.Type) > 0 {
+		return json.Unmarshal(aux.Type, &t.Type)
+	}
+	return nil
 }
 
 // MarshalJSON is used to serialize a schema object or boolean.
@@ -1121,7 +2088,12 @@ func (t *Schema) MarshalJSON() ([]byte, error) {
 		return []byte("true"), nil
 	}
 	type SchemaAlt Schema
-	b, err := json.Marshal((*SchemaAlt)(t))
+	var v any = (*SchemaAlt)(t)
+	if len(t.Types) > 0 {
+		// the list of types is output instead of the single type
+		v = t.withTypeList()
+	}
+	b, err := json.Marshal(v)
 	if err != nil {
 		return nil, err
 	}
@@ -1145,7 +2117,7 @@ func (r *Reflector) typeName(t reflect.Type) string {
 			return name
 		}
 	}
-	return t.Name()
+	return genericTypeName(t)
 }
 
 // Split on commas that are not preceded by `\`.
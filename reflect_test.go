@@ -10,6 +10,8 @@ import (
 	"path/filepath"
 	"reflect"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -492,11 +494,15 @@ func TestBaselineUnmarshal(t *testing.T) {
 }
 
 func compareSchemaOutput(t *testing.T, f string, r *Reflector, obj any) {
+	t.Helper()
+	compareSchemaFixture(t, f, r.Reflect(obj))
+}
+
+func compareSchemaFixture(t *testing.T, f string, actualSchema *Schema) {
 	t.Helper()
 	expectedJSON, err := os.ReadFile(f)
 	require.NoError(t, err)
 
-	actualSchema := r.Reflect(obj)
 	actualJSON, _ := json.MarshalIndent(actualSchema, "", "  ") //nolint:errchkjson
 
 	if *updateFixtures {
@@ -784,3 +790,689 @@ func TestJSONStringTagRequiresExactOptionMatch(t *testing.T) {
 	require.Nil(t, pa.MinLength)
 	require.Equal(t, json.Number("3"), pa.Minimum)
 }
+
+type TuplePoint struct {
+	_ struct{} `jsonschema:"tuple"`
+	X float64  `jsonschema:"description=longitude"`
+	Y float64  `jsonschema:"description=latitude"`
+}
+
+type TupleRange struct {
+	From int
+	To   int `jsonschema:"minimum=1"`
+}
+
+func TestTupleHandling(t *testing.T) {
+	type TupleHandler struct {
+		Point  TuplePoint   `json:"point"`
+		Range  TupleRange   `json:"range"`
+		Coords [3]float64   `json:"coords" jsonschema:"tuple,minimum=0"`
+		Fixed  [2]string    `json:"fixed"`
+		Flags  [2]bool      `json:"flags"`
+		Points []TuplePoint `json:"points,omitempty"`
+	}
+
+	r := &Reflector{
+		AsTuple: func(t reflect.Type) bool {
+			return t == reflect.TypeOf(TupleRange{}) || t == reflect.TypeOf([2]bool{})
+		},
+	}
+	compareSchemaOutput(t, "fixtures/tuple.json", r, &TupleHandler{})
+	fixtureContains(t, "fixtures/tuple.json", `"prefixItems"`)
+	fixtureContains(t, "fixtures/tuple.json", `"items": false`)
+}
+
+type TupleObjectTags struct {
+	_    struct{} `jsonschema:"tuple"`
+	Name string   `jsonschema:"oneof_required=name,dependentRequired=Code"`
+	Code string   `jsonschema:"anyof_required=code,minLength=2"`
+}
+
+func TestTupleObjectKeywords(t *testing.T) {
+	r := &Reflector{}
+	s := r.Reflect(&TupleObjectTags{}).Definitions["TupleObjectTags"]
+	assert.Equal(t, "array", s.Type)
+	assert.Empty(t, s.OneOf)
+	assert.Empty(t, s.AnyOf)
+	assert.Empty(t, s.DependentRequired)
+	require.Len(t, s.PrefixItems, 2)
+	assert.Equal(t, uint64(2), *s.PrefixItems[1].MinLength)
+}
+
+type EmbeddedEntity struct {
+	ID        string    `json:"id" jsonschema:"minLength=1"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type EmbeddedCustomer struct {
+	EmbeddedEntity
+	Name string `json:"name"`
+}
+
+type EmbeddedOrder struct {
+	*EmbeddedEntity
+	Inner    `json:",inline"`
+	Customer EmbeddedCustomer `json:"customer"`
+	Notes    string           `json:"notes,omitempty"`
+}
+
+func TestEmbeddedAsAllOf(t *testing.T) {
+	r := &Reflector{EmbeddedAsAllOf: true}
+	compareSchemaOutput(t, "fixtures/embedded_allof.json", r, &EmbeddedOrder{})
+
+	s := r.Reflect(&EmbeddedOrder{})
+	entity := s.Definitions["EmbeddedEntity"]
+	require.NotNil(t, entity)
+	assert.Equal(t, FalseSchema, entity.AdditionalProperties)
+	open := s.Definitions["EmbeddedEntityOpen"]
+	require.NotNil(t, open)
+	assert.Nil(t, open.AdditionalProperties)
+	assert.Equal(t, entity.Properties.Len(), open.Properties.Len())
+	customer := s.Definitions["EmbeddedCustomer"]
+	require.Len(t, customer.AllOf, 2)
+	assert.Equal(t, "#/$defs/EmbeddedEntityOpen", customer.AllOf[0].Ref)
+	assert.Equal(t, []string{"name"}, customer.AllOf[1].Required)
+	assert.Equal(t, FalseSchema, customer.UnevaluatedProperties)
+}
+
+type EmbeddedVIP struct {
+	EmbeddedCustomer
+	Level  int            `json:"level"`
+	Entity EmbeddedEntity `json:"entity"`
+}
+
+func TestEmbeddedAsAllOfNested(t *testing.T) {
+	r := &Reflector{EmbeddedAsAllOf: true}
+	s := r.Reflect(&EmbeddedVIP{})
+
+	// the shared definitions used directly remain closed
+	assert.Equal(t, FalseSchema, s.Definitions["EmbeddedEntity"].AdditionalProperties)
+	assert.Equal(t, FalseSchema, s.Definitions["EmbeddedCustomer"].UnevaluatedProperties)
+	p, _ := s.Definitions["EmbeddedVIP"].AllOf[1].Properties.Get("entity")
+	assert.Equal(t, "#/$defs/EmbeddedEntity", p.Ref)
+
+	vip := s.Definitions["EmbeddedVIP"]
+	assert.Equal(t, "#/$defs/EmbeddedCustomerOpen", vip.AllOf[0].Ref)
+	assert.Equal(t, FalseSchema, vip.UnevaluatedProperties)
+	customer := s.Definitions["EmbeddedCustomerOpen"]
+	require.NotNil(t, customer)
+	assert.Nil(t, customer.UnevaluatedProperties)
+	assert.Equal(t, "#/$defs/EmbeddedEntityOpen", customer.AllOf[0].Ref)
+	assert.Nil(t, s.Definitions["EmbeddedEntityOpen"].AdditionalProperties)
+}
+
+type InlineAddress struct {
+	Street string `json:"street"`
+}
+
+type InlineContact struct {
+	Email string `json:"email" jsonschema:"format=email"`
+}
+
+type InlineNode struct {
+	Value    int           `json:"value"`
+	Children []*InlineNode `json:"children,omitempty"`
+}
+
+type InlineUser struct {
+	Home    InlineAddress `json:"home"`
+	Work    InlineAddress `json:"work"`
+	Contact InlineContact `json:"contact" jsonschema:"description=Preferred contact"`
+	Tree    InlineNode    `json:"tree"`
+}
+
+func TestInlineSingleUse(t *testing.T) {
+	r := &Reflector{InlineSingleUse: true}
+	compareSchemaOutput(t, "fixtures/inline_single_use.json", r, &InlineUser{})
+
+	s, refs := r.ReflectMany(&InlineUser{}, &InlineContact{})
+	assert.Equal(t, []string{"#/$defs/InlineUser", "#/$defs/InlineContact"}, refs)
+	assert.Equal(t, []string{"InlineAddress", "InlineContact", "InlineNode", "InlineUser"}, sortedKeys(s.Definitions))
+	assert.Empty(t, UnresolvedRefs(s))
+
+	// the root definition is kept, even when used once
+	s = r.Reflect(&InlineContact{})
+	assert.Equal(t, "#/$defs/InlineContact", s.Ref)
+	assert.Contains(t, s.Definitions, "InlineContact")
+
+	// as are those with references inside of them
+	s = &Schema{
+		Ref: "#/$defs/Root",
+		Definitions: Definitions{
+			"Root": {Properties: NewProperties()},
+			"A":    {Properties: NewProperties()},
+			"B":    {Type: "string"},
+		},
+	}
+	s.Definitions["Root"].Properties.Set("a", &Schema{Ref: "#/$defs/A"})
+	s.Definitions["Root"].Properties.Set("b", &Schema{Ref: "#/$defs/A/properties/b"})
+	s.Definitions["A"].Properties.Set("b", &Schema{Ref: "#/$defs/B"})
+	inlineSingleUseDefinitions(s, nil)
+	assert.Equal(t, []string{"A", "Root"}, sortedKeys(s.Definitions))
+	assert.Equal(t, "string", s.Definitions["A"].Properties.Value("b").Type)
+	assert.Empty(t, UnresolvedRefs(s))
+}
+
+type AnnotatedFields struct {
+	ID      int     `json:"id" jsonschema:"readOnly"`
+	Version int     `json:"version" jsonschema:"const=2,example=2"`
+	Kind    string  `json:"kind" jsonschema:"const=user,readOnly=true"`
+	Active  bool    `json:"active" jsonschema:"const=true,example=true"`
+	Old     string  `json:"old,omitempty" jsonschema:"deprecated"`
+	Secret  Inner   `json:"secret" jsonschema:"writeOnly,deprecated=true"`
+	Ratio   float64 `json:"ratio,string" jsonschema:"const=0.5"`
+	Any     any     `json:"any,omitempty" jsonschema:"readOnly"`
+	Tags    []int   `json:"tags,omitempty" jsonschema:"readOnly,example=1"`
+	Ref     Inner   `json:"ref" jsonschema:"const=abc,example=abc"`
+	Untyped any     `json:"untyped" jsonschema:"const=abc,example=json:\"abc\""`
+}
+
+func TestAnnotationKeywords(t *testing.T) {
+	compareSchemaOutput(t, "fixtures/annotations.json", &Reflector{}, &AnnotatedFields{})
+}
+
+type JSONValuesSettings struct {
+	Retries int      `json:"retries"`
+	Hosts   []string `json:"hosts,omitempty"`
+}
+
+type JSONValues struct {
+	Settings JSONValuesSettings `json:"settings" jsonschema_default:"{\"retries\":3,\"hosts\":[\"a\",\"b\"]}"`
+	Labels   map[string]int     `json:"labels,omitempty" jsonschema:"example=json:{\"a\":1\\,\"b\":2}"`
+	Ports    []int              `json:"ports" jsonschema:"default=json:[80\\,443]" jsonschema_examples:"[[8080],[]]"`
+	Mode     string             `json:"mode" jsonschema_enum:"[\"fast, but risky\",\"slow\"]" jsonschema:"const=json:\"slow\""`
+	Note     *string            `json:"note" jsonschema:"nullable,default=json:null"`
+	Level    int                `json:"level" jsonschema:"default=json:1.5,example=json:2"`
+	Invalid  []int              `json:"invalid,omitempty" jsonschema:"default=json:{\"a\":1}" jsonschema_enum:"[1,2"`
+}
+
+func TestJSONTagValues(t *testing.T) {
+	compareSchemaOutput(t, "fixtures/json_tag_values.json", &Reflector{}, &JSONValues{})
+
+	s, err := (&Reflector{}).TryReflect(&JSONValues{})
+	assert.Nil(t, s)
+	assert.EqualError(t, err, `github.com/invopop/jsonschema.JSONValues.Level: default=json:1.5: value does not match the field's schema
+github.com/invopop/jsonschema.JSONValues.Invalid: default=json:{"a":1}: value does not match the field's schema
+github.com/invopop/jsonschema.JSONValues.Invalid: jsonschema_enum: unexpected EOF`)
+}
+
+type ObjectKeywordsPayment struct {
+	_          struct{}          `jsonschema:"minProperties=1,maxProperties=3"`
+	CardNumber string            `json:"card_number,omitempty"`
+	CVV        string            `json:"cvv,omitempty" jsonschema:"dependentRequired=card_number"`
+	Expiry     string            `json:"expiry,omitempty" jsonschema:"dependentRequired=card_number;cvv"`
+	Metadata   map[string]string `json:"metadata,omitempty" jsonschema:"minProperties=1,maxProperties=10,propertyNames=^[a-z_]+$"`
+	Headers    map[string]any    `json:"headers,omitempty" jsonschema:"patternProperties=^X-,patternProperties=^Content-"`
+	Counts     map[int]int       `json:"counts,omitempty" jsonschema:"patternProperties=^-[0-9]+$"`
+	Billing    Inner             `json:"billing,omitempty" jsonschema:"maxProperties=1,patternProperties=^x-"`
+}
+
+func TestObjectKeywords(t *testing.T) {
+	compareSchemaOutput(t, "fixtures/object_keywords.json", &Reflector{}, &ObjectKeywordsPayment{})
+}
+
+type ConditionsShipping struct {
+	Method  string `json:"method" jsonschema:"enum=pickup,enum=delivery,enum=courier"`
+	Address string `json:"address,omitempty"`
+	Phone   string `json:"phone,omitempty"`
+	Store   string `json:"store_id,omitempty"`
+}
+
+func (ConditionsShipping) JSONSchemaConditions() []Condition {
+	return []Condition{
+		{
+			When:    map[string]any{"method": []string{"delivery", "courier"}},
+			Require: []string{"Address", "phone"},
+		},
+		{
+			When:    map[string]any{"Method": "pickup"},
+			Require: []string{"Store"},
+			Else:    &Schema{Not: &Schema{Required: []string{"store_id"}}},
+		},
+	}
+}
+
+type ConditionsPayment struct {
+	Card   bool   `json:"card"`
+	Number string `json:"number,omitempty"`
+}
+
+func (ConditionsPayment) JSONSchemaConditions() []Condition {
+	return []Condition{{When: map[string]any{"card": true}, Require: []string{"Number"}}}
+}
+
+type ConditionsOrder struct {
+	Shipping ConditionsShipping `json:"shipping"`
+	Payment  ConditionsPayment  `json:"payment"`
+}
+
+type ConditionsInvalid struct {
+	Name string `json:"name"`
+}
+
+func (ConditionsInvalid) JSONSchemaConditions() []Condition {
+	return []Condition{
+		{When: map[string]any{"missing": 1}, Require: []string{"Name", "other"}},
+		{When: map[string]any{"Name": "a"}, Require: []string{"name"}},
+	}
+}
+
+func TestConditions(t *testing.T) {
+	compareSchemaOutput(t, "fixtures/conditions.json", &Reflector{}, &ConditionsOrder{})
+
+	// conditions on unknown properties are skipped, and reported
+	r := &Reflector{}
+	s := r.Reflect(&ConditionsInvalid{}).Definitions["ConditionsInvalid"]
+	require.NotNil(t, s.If)
+	assert.Equal(t, []string{"name"}, s.If.Required)
+	assert.Empty(t, s.AllOf)
+
+	_, err := r.TryReflect(&ConditionsInvalid{})
+	assert.EqualError(t, err, `github.com/invopop/jsonschema.ConditionsInvalid: condition refers to unknown properties ["missing" "other"]`)
+}
+
+type MapKeyColor string
+
+func (MapKeyColor) JSONSchemaExtend(s *Schema) {
+	s.Enum = []any{"red", "green", "blue"}
+}
+
+type MapKeyPoint struct {
+	X, Y int
+}
+
+func (p MapKeyPoint) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("%d,%d", p.X, p.Y)), nil
+}
+
+type MapKeyCode string
+
+func (MapKeyCode) JSONSchema() *Schema {
+	return &Schema{Type: "string", Pattern: "^[A-Z]{3}$"}
+}
+
+type MapKeys struct {
+	Names    map[string]int      `json:"names"`
+	Signed   map[int8]string     `json:"signed"`
+	Unsigned map[uint64]string   `json:"unsigned"`
+	Colors   map[MapKeyColor]int `json:"colors"`
+	Points   map[MapKeyPoint]int `json:"points"`
+	Codes    map[MapKeyCode]int  `json:"codes"`
+	Even     map[int]bool        `json:"even" jsonschema:"propertyNames=[02468]$"`
+	Prefixed map[string]bool     `json:"prefixed" jsonschema:"propertyNames=^x-,propertyNames=^x-[a-z]+$"`
+}
+
+func TestMapKeys(t *testing.T) {
+	compareSchemaOutput(t, "fixtures/map_keys.json", &Reflector{}, &MapKeys{})
+}
+
+type ContentInvoice struct {
+	Number string `json:"number"`
+	Total  int    `json:"total"`
+}
+
+type ContentMessage struct {
+	Document   []byte                     `json:"document" jsonschema:"contentMediaType=application/pdf"`
+	Signature  string                     `json:"signature" jsonschema:"contentEncoding=base64url"`
+	Payload    string                     `json:"payload" jsonschema:"contentMediaType=application/json,contentSchema=invoice"`
+	Unknown    string                     `json:"unknown,omitempty" jsonschema:"contentSchema=missing"`
+	Invoice    Embedded[ContentInvoice]   `json:"invoice"`
+	Invoices   Embedded[[]ContentInvoice] `json:"invoices,omitempty"`
+	Attachment *Embedded[string]          `json:"attachment,omitempty"`
+}
+
+func TestContentKeywords(t *testing.T) {
+	r := &Reflector{ContentSchemaTypes: map[string]any{"invoice": ContentInvoice{}}}
+	compareSchemaOutput(t, "fixtures/content.json", r, &ContentMessage{})
+}
+
+func TestEmbeddedJSON(t *testing.T) {
+	m := ContentMessage{Invoice: Embedded[ContentInvoice]{Value: ContentInvoice{Number: "A1", Total: 10}}}
+	data, err := json.Marshal(m.Invoice)
+	require.NoError(t, err)
+	assert.Equal(t, `"{\"number\":\"A1\",\"total\":10}"`, string(data))
+
+	var inv Embedded[ContentInvoice]
+	require.NoError(t, json.Unmarshal(data, &inv))
+	assert.Equal(t, m.Invoice, inv)
+	assert.Error(t, json.Unmarshal([]byte(`{"number":"A1"}`), &inv))
+}
+
+type NullableFields struct {
+	Name     *string           `json:"name"`
+	Level    *string           `json:"level" jsonschema:"enum=low,enum=high"`
+	Tags     []string          `json:"tags"`
+	Labels   map[string]string `json:"labels"`
+	Inner    *Inner            `json:"inner"`
+	Optional *int              `json:"optional,omitempty"`
+	Data     json.RawMessage   `json:"data"`
+	Count    int               `json:"count" jsonschema:"nullable"`
+	Plain    string            `json:"plain"`
+}
+
+func TestNullableFromType(t *testing.T) {
+	tests := []struct {
+		fixture string
+		style   NullableStyle
+	}{
+		{"fixtures/nullable_oneof.json", NullableOneOf},
+		{"fixtures/nullable_anyof.json", NullableAnyOf},
+		{"fixtures/nullable_type_array.json", NullableTypeArray},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			r := &Reflector{NullableFromType: true, NullableAs: tt.style}
+			compareSchemaOutput(t, tt.fixture, r, &NullableFields{})
+		})
+	}
+}
+
+func TestSchemaTypesJSON(t *testing.T) {
+	s := new(Schema)
+	require.NoError(t, json.Unmarshal([]byte(`{"type":["string","null"],"minLength":1}`), s))
+	assert.Empty(t, s.Type)
+	assert.Equal(t, []string{"string", "null"}, s.Types)
+	data, err := json.Marshal(s)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"type":["string","null"],"minLength":1}`, string(data))
+
+	// the list is output in the same position as a single type
+	s = &Schema{Types: []string{"string", "null"}, MinLength: s.MinLength, Title: "Name", Extras: map[string]any{"x": 1}}
+	data, err = json.Marshal(s)
+	require.NoError(t, err)
+	assert.Equal(t, `{"type":["string","null"],"minLength":1,"title":"Name","x":1}`, string(data))
+
+	s = new(Schema)
+	require.NoError(t, json.Unmarshal([]byte(`{"type":"string"}`), s))
+	assert.Equal(t, "string", s.Type)
+	assert.Nil(t, s.Types)
+
+	assert.Error(t, json.Unmarshal([]byte(`{"type":1}`), new(Schema)))
+}
+
+type TypeListFields struct {
+	Name  *string `json:"name" jsonschema:"type=string;null,minLength=1,default=json:null"`
+	Value any     `json:"value" jsonschema:"type=string;number,default=json:2.5,example=json:\"x\",enum=json:true"`
+	Count int     `json:"count" jsonschema:"type=integer;null,minimum=0,example=3"`
+}
+
+type TypeListAlternatives struct {
+	OneOf any `json:"one_of" jsonschema:"type=string;null,oneof_type=string;integer"`
+	AnyOf any `json:"any_of" jsonschema:"type=string;null,anyof_type=string;integer"`
+}
+
+func TestTypeListTags(t *testing.T) {
+	compareSchemaOutput(t, "fixtures/type_list.json", &Reflector{}, &TypeListFields{})
+
+	s := Reflect(&TypeListAlternatives{}).Definitions["TypeListAlternatives"]
+	for _, name := range []string{"one_of", "any_of"} {
+		p := s.Properties.Value(name)
+		assert.Empty(t, p.Type, name)
+		assert.Empty(t, p.Types, name)
+	}
+	assert.Len(t, s.Properties.Value("one_of").OneOf, 2)
+	assert.Len(t, s.Properties.Value("any_of").AnyOf, 2)
+}
+
+type JSONv2Base struct {
+	ID string `json:"id"`
+}
+
+type JSONv2Audit struct {
+	CreatedBy string `json:"created_by"`
+}
+
+type JSONv2Fields struct {
+	JSONv2Base
+	Audit     JSONv2Audit       `json:",inline"`
+	Quoted    string            `json:"'a,b',omitempty"`
+	Raw       []byte            `json:"raw,format:base64url"`
+	Hex       []byte            `json:"hex,omitzero,format:hex"`
+	Bytes     []byte            `json:"bytes,format:array"`
+	Hash      [4]byte           `json:"hash"`
+	Ratio     float64           `json:"ratio,format:nonfinite"`
+	Created   time.Time         `json:"created,format:unixmilli"`
+	Day       *time.Time        `json:"day,format:DateOnly"`
+	Timeout   time.Duration     `json:"timeout,format:iso8601"`
+	Tags      []string          `json:"tags"`
+	Labels    map[string]string `json:"labels,format:emitnull"`
+	Parent    *JSONv2Base       `json:"parent"`
+	Remaining map[string]int    `json:",unknown"`
+	Count     int               `json:"count,omitempty"`
+	Enabled   bool              `json:"enabled,omitempty,string"`
+	Limit     uint              `json:"limit,omitempty,omitzero,string"`
+	Stamp     *int              `json:"stamp,omitempty"`
+}
+
+func TestJSONv2(t *testing.T) {
+	r := &Reflector{JSONv2: true, NullableFromType: true, NullableAs: NullableTypeArray}
+	compareSchemaOutput(t, "fixtures/json_v2.json", r, &JSONv2Fields{})
+}
+
+type ValidateFields struct {
+	Name     string            `json:"name,omitempty" validate:"required,min=1,max=64"`
+	Email    string            `json:"email,omitempty" validate:"omitempty,email"`
+	Website  *string           `json:"website,omitempty" validate:"omitempty,url"`
+	ID       string            `json:"id,omitempty" validate:"uuid"`
+	Host     string            `json:"host,omitempty" validate:"hostname|ip"`
+	Address  string            `json:"address,omitempty" validate:"ip"`
+	Color    string            `json:"color,omitempty" validate:"oneof=red green 'light blue'"`
+	Level    int               `json:"level,omitempty" validate:"oneof=1 2 3"`
+	Age      int               `json:"age,omitempty" validate:"gte=0,lt=130"`
+	Ratio    float64           `json:"ratio,omitempty" validate:"gt=0,lte=1.5"`
+	Code     string            `json:"code,omitempty" validate:"len=3" jsonschema:"maxLength=4"`
+	Tags     []string          `json:"tags,omitempty" validate:"required,gt=0,lt=11,dive,min=2"`
+	Matrix   [][]int           `json:"matrix,omitempty" validate:"dive,len=2,dive,min=0"`
+	Labels   map[string]string `json:"labels,omitempty" validate:"max=5,dive,keys,alpha,max=10,endkeys,required,max=20"`
+	Payload  []byte            `json:"payload,omitempty" validate:"max=100"`
+	Internal string            `json:"internal,omitempty" validate:"-"`
+	Nickname string            `json:"nickname,omitempty" validate:"omitempty,min=3"`
+	Priority int               `json:"priority,omitempty" validate:"omitempty,oneof=1 2"`
+	Limit    *int              `json:"limit,omitempty" validate:"omitempty,max=10"`
+	Aliases  []string          `json:"aliases,omitempty" validate:"omitempty,max=3,dive,omitempty,min=2"`
+}
+
+func TestValidateTag(t *testing.T) {
+	r := &Reflector{ValidateTag: "validate"}
+	compareSchemaOutput(t, "fixtures/validate_tag.json", r, &ValidateFields{})
+}
+
+type ValidateEnumFields struct {
+	Color  string   `json:"color" validate:"oneof=red green" jsonschema:"enum=blue,enum=red"`
+	Level  int      `json:"level" validate:"omitempty,oneof=1 2" jsonschema:"enum=3"`
+	Colors []string `json:"colors" validate:"dive,oneof=red green" jsonschema:"enum=blue"`
+	Mode   string   `json:"mode" validate:"oneof=a b" jsonschema:"enum=json:\"c\""`
+	Kind   string   `json:"kind" validate:"oneof=a b"`
+}
+
+func TestValidateTagEnumPrecedence(t *testing.T) {
+	r := &Reflector{ValidateTag: "validate"}
+	s := r.Reflect(&ValidateEnumFields{}).Definitions["ValidateEnumFields"]
+	assert.Equal(t, []any{"blue", "red"}, s.Properties.Value("color").Enum)
+	assert.Equal(t, []any{json.Number("3")}, s.Properties.Value("level").Enum)
+	assert.Equal(t, []any{"blue"}, s.Properties.Value("colors").Items.Enum)
+	assert.Equal(t, []any{"c"}, s.Properties.Value("mode").Enum)
+	assert.Equal(t, []any{"a", "b"}, s.Properties.Value("kind").Enum)
+}
+
+var cachedExtendCalls atomic.Int32
+
+type CachedExtend struct {
+	Name  string     `json:"name"`
+	Inner Inner      `json:"inner"`
+	Point TuplePoint `json:"point"`
+}
+
+func (CachedExtend) JSONSchemaExtend(s *Schema) {
+	cachedExtendCalls.Add(1)
+	s.Title = "Cached"
+}
+
+type CachedOwner struct {
+	Cached CachedExtend `json:"cached"`
+	Other  Inner        `json:"other"`
+}
+
+func TestReflectorCache(t *testing.T) {
+	cachedExtendCalls.Store(0)
+	r := &Reflector{CacheSchemas: true}
+	s1 := r.Reflect(&CachedExtend{})
+	s2 := r.Reflect(CachedExtend{})
+	assert.NotSame(t, s1, s2)
+	assert.True(t, s1.Equal(s2))
+	assert.EqualValues(t, 1, cachedExtendCalls.Load())
+
+	// cached definitions are reused by other types, and are not shared
+	s := r.Reflect(&CachedOwner{})
+	assert.EqualValues(t, 1, cachedExtendCalls.Load())
+	assert.True(t, s1.Definitions["CachedExtend"].Equal(s.Definitions["CachedExtend"]))
+	assert.Contains(t, s.Definitions, "Inner")
+	assert.Contains(t, s.Definitions, "TuplePoint")
+	s.Definitions["CachedExtend"].Title = "Changed"
+	assert.Equal(t, "Cached", r.Reflect(&CachedOwner{}).Definitions["CachedExtend"].Title)
+
+	r.ExpandedStruct = true
+	s3 := r.Reflect(&CachedExtend{})
+	assert.NotSame(t, s1, s3)
+	assert.Equal(t, "Cached", s3.Title)
+	assert.EqualValues(t, 2, cachedExtendCalls.Load())
+
+	r.ClearCache()
+	s4 := r.Reflect(&CachedExtend{})
+	assert.NotSame(t, s3, s4)
+	assert.EqualValues(t, 3, cachedExtendCalls.Load())
+
+	r = &Reflector{}
+	r.Reflect(&CachedExtend{})
+	r.Reflect(&CachedExtend{})
+	assert.EqualValues(t, 5, cachedExtendCalls.Load())
+}
+
+func TestReflectorConcurrency(t *testing.T) {
+	r := &Reflector{CacheSchemas: true}
+	types := []any{&TestUser{}, &PetOwner{}, &CachedExtend{}, &RecursiveExample{}}
+	expected := make([][]byte, len(types))
+	for i, typ := range types {
+		expected[i], _ = json.Marshal((&Reflector{}).Reflect(typ)) //nolint:errchkjson
+	}
+
+	var wg sync.WaitGroup
+	for range 8 {
+		for i, typ := range types {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				data, err := json.Marshal(r.Reflect(typ))
+				assert.NoError(t, err)
+				assert.JSONEq(t, string(expected[i]), string(data))
+			}()
+		}
+	}
+	wg.Wait()
+}
+
+func TestReflectorReentrant(t *testing.T) {
+	r := &Reflector{CacheSchemas: true}
+	r.Mapper = func(t reflect.Type) *Schema {
+		if t != reflect.TypeOf(Inner{}) {
+			return nil
+		}
+		s := r.Reflect(&TestUser{}).Clone()
+		s.Version = ""
+		return s
+	}
+
+	done := make(chan *Schema)
+	go func() {
+		done <- r.Reflect(&CachedExtend{})
+	}()
+	select {
+	case s := <-done:
+		require.NotNil(t, s.Definitions["CachedExtend"])
+		inner := s.Definitions["CachedExtend"].Properties.Value("inner")
+		assert.Equal(t, "#/$defs/TestUser", inner.Ref)
+		assert.Contains(t, inner.Definitions, "TestUser")
+		assert.NotContains(t, s.Definitions, "Inner")
+	case <-time.After(5 * time.Second):
+		t.Fatal("reflecting from a Mapper did not complete")
+	}
+}
+
+func TestReflectorCacheCopy(t *testing.T) {
+	r := &Reflector{
+		CacheSchemas: true,
+		Mapper: func(t reflect.Type) *Schema {
+			if t == reflect.TypeOf(Inner{}) {
+				return &Schema{Type: "string"}
+			}
+			return nil
+		},
+	}
+	s := r.Reflect(&CachedExtend{})
+	assert.Equal(t, "string", s.Definitions["CachedExtend"].Properties.Value("inner").Type)
+
+	// a copy with a different Mapper does not use the original's cache
+	c := *r
+	c.Mapper = nil
+	s = c.Reflect(&CachedExtend{})
+	assert.Equal(t, "#/$defs/Inner", s.Definitions["CachedExtend"].Properties.Value("inner").Ref)
+	assert.Contains(t, s.Definitions, "Inner")
+
+	// neither does the original use the copy's
+	s = r.Reflect(&CachedExtend{})
+	assert.Equal(t, "string", s.Definitions["CachedExtend"].Properties.Value("inner").Type)
+	assert.NotContains(t, s.Definitions, "Inner")
+
+	// nor does the original once its Mapper is replaced by another closure
+	r.Mapper = func(t reflect.Type) *Schema {
+		if t == reflect.TypeOf(Inner{}) {
+			return &Schema{Type: "integer"}
+		}
+		return nil
+	}
+	s = r.Reflect(&CachedExtend{})
+	assert.Equal(t, "integer", s.Definitions["CachedExtend"].Properties.Value("inner").Type)
+}
+
+func TestReflectMany(t *testing.T) {
+	r := &Reflector{BaseSchemaID: "https://example.com/schemas"}
+	s, refs := r.ReflectMany(&LookupUser{}, &PetOwner{}, OuterNamed{}, []string{})
+	assert.Equal(t, []string{"#/$defs/LookupUser", "#/$defs/PetOwner", "#/$defs/OuterNamed", ""}, refs)
+	compareSchemaFixture(t, "fixtures/reflect_many.json", s)
+}
+
+func TestReflectManyNil(t *testing.T) {
+	r := &Reflector{}
+	s, refs := r.ReflectMany(nil, &PetOwner{}, nil)
+	assert.Equal(t, []string{"", "#/$defs/PetOwner", ""}, refs)
+	assert.Len(t, s.AnyOf, 1)
+	assert.Equal(t, ID("https://github.com/invopop/jsonschema"), s.ID)
+
+	s, refs = r.ReflectManyFromTypes(nil)
+	assert.Equal(t, []string{""}, refs)
+	assert.Empty(t, s.AnyOf)
+	assert.Equal(t, EmptyID, s.ID)
+}
+
+func TestReflectManyLookup(t *testing.T) {
+	r := &Reflector{
+		Lookup: func(i reflect.Type) ID {
+			if i == reflect.TypeOf(LookupName{}) {
+				return ID("https://example.com/schemas/lookup-name")
+			}
+			return EmptyID
+		},
+	}
+	s, refs := r.ReflectManyFromTypes(reflect.TypeOf(LookupName{}), reflect.TypeOf(&LookupUser{}))
+	assert.EqualValues(t, "https://github.com/invopop/jsonschema", s.ID)
+	assert.Equal(t, []string{"https://example.com/schemas/lookup-name", "#/$defs/LookupUser"}, refs)
+	assert.Len(t, s.Definitions, 1)
+
+	r = &Reflector{DoNotReference: true, Anonymous: true}
+	s, refs = r.ReflectMany(&LookupUser{}, &LookupName{})
+	assert.Equal(t, []string{"", ""}, refs)
+	assert.Empty(t, s.ID)
+	assert.Nil(t, s.Definitions)
+	require.Len(t, s.AnyOf, 2)
+	assert.Equal(t, "object", s.AnyOf[0].Type)
+}
@@ -0,0 +1,159 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// defsRefPrefix is used by references to the root schema's definitions.
+const defsRefPrefix = "#/$defs/"
+
+// SplitDefinitions breaks a schema, usually the result of a Reflector, into
+// a set of independent schemas, one per definition, so that each can be
+// published at its own URL. Definitions are assigned an `$id` by adding the
+// snake case version of their name to the base ID, unless they already have
+// one, and all `#/$defs/...` references are rewritten to use the new IDs.
+//
+// The root schema is only included in the results if it contains something
+// more than a reference to one of its definitions, in which case it must
+// have an ID. The original schema is not modified.
+func SplitDefinitions(s *Schema, base ID) (map[ID]*Schema, error) {
+	if base == EmptyID {
+		return nil, errors.New("base schema ID is required")
+	}
+
+	ids := make(map[string]ID, len(s.Definitions))
+	names := make(map[ID]string, len(s.Definitions))
+	for _, name := range sortedKeys(s.Definitions) {
+		id := s.Definitions[name].ID
+		if id == EmptyID {
+			id = base.Add(ToSnakeCase(name))
+		}
+		if other, ok := names[id]; ok {
+			return nil, fmt.Errorf("definitions %q and %q share the same ID %q", other, name, id)
+		}
+		ids[name] = id
+		names[id] = name
+	}
+
+	schemas := make(map[ID]*Schema, len(s.Definitions)+1)
+	for name, def := range s.Definitions {
+		d := def.clone()
+		if d.boolean != nil {
+			// boolean schemas cannot have an ID, so use the object equivalent
+			if *d.boolean {
+				d = &Schema{}
+			} else {
+				d = &Schema{Not: &Schema{}}
+			}
+		}
+		d.Version = Version
+		d.ID = ids[name]
+		if err := rewriteDefinitionRefs(d, ids); err != nil {
+			return nil, fmt.Errorf("definition %q: %w", name, err)
+		}
+		schemas[d.ID] = d
+	}
+
+	root := s.clone()
+	root.Version = ""
+	root.ID = EmptyID
+	root.Definitions = nil
+	if strings.HasPrefix(root.Ref, defsRefPrefix) && reflect.DeepEqual(root, &Schema{Ref: root.Ref}) {
+		return schemas, nil
+	}
+	if s.ID == EmptyID {
+		return nil, errors.New("root schema requires an ID")
+	}
+	if name, ok := names[s.ID]; ok {
+		return nil, fmt.Errorf("root schema and definition %q share the same ID %q", name, s.ID)
+	}
+	root.Version = Version
+	root.ID = s.ID
+	if err := rewriteDefinitionRefs(root, ids); err != nil {
+		return nil, fmt.Errorf("root: %w", err)
+	}
+	schemas[root.ID] = root
+
+	return schemas, nil
+}
+
+// WriteSplitDefinitions splits the schema using SplitDefinitions and writes
+// each of the resulting schemas to the provided directory, in a JSON file
+// named after the last path element of its ID. An error is returned, before
+// any file is written, if two schemas would share the same file name.
+func WriteSplitDefinitions(dir string, s *Schema, base ID) error {
+	schemas, err := SplitDefinitions(s, base)
+	if err != nil {
+		return err
+	}
+	ids := make([]ID, 0, len(schemas))
+	for id := range schemas {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	files := make(map[string]ID, len(ids))
+	for _, id := range ids {
+		u, err := url.Parse(id.String())
+		if err != nil {
+			return fmt.Errorf("invalid ID %q: %w", id, err)
+		}
+		name := path.Base(u.Path)
+		if name == "/" || name == "." {
+			return fmt.Errorf("ID %q has no path to use as a file name", id)
+		}
+		if path.Ext(name) != ".json" {
+			name += ".json"
+		}
+		if other, ok := files[name]; ok {
+			return fmt.Errorf("IDs %q and %q share the same file name %q", other, id, name)
+		}
+		files[name] = id
+	}
+
+	for name, id := range files {
+		data, err := json.MarshalIndent(schemas[id], "", "  ")
+		if err != nil {
+			return fmt.Errorf("marshalling %q: %w", id, err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rewriteDefinitionRefs replaces all the references to the root definitions
+// inside the schema with references to their new IDs.
+func rewriteDefinitionRefs(s *Schema, ids map[string]ID) error {
+	var err error
+	s.walk("", func(ptr string, x *Schema) bool {
+		if err != nil {
+			return false
+		}
+		if !strings.HasPrefix(x.Ref, defsRefPrefix) {
+			return true
+		}
+		name, rest, _ := strings.Cut(strings.TrimPrefix(x.Ref, defsRefPrefix), "/")
+		id, ok := ids[unescapePointer(name)]
+		if !ok {
+			err = fmt.Errorf("unknown reference %q at %q", x.Ref, ptr)
+			return false
+		}
+		x.Ref = id.String()
+		if rest != "" {
+			x.Ref += "#/" + rest
+		}
+		return true
+	})
+	return err
+}
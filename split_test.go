@@ -0,0 +1,122 @@
+package jsonschema
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitDefinitions(t *testing.T) {
+	r := &Reflector{BaseSchemaID: "https://example.com/schemas"}
+	s := r.Reflect(&PetOwner{})
+
+	schemas, err := SplitDefinitions(s, r.BaseSchemaID)
+	require.NoError(t, err)
+	require.Len(t, schemas, 3)
+
+	owner := schemas["https://example.com/schemas/pet-owner"]
+	require.NotNil(t, owner)
+	assert.Equal(t, Version, owner.Version)
+	assert.Nil(t, owner.Definitions)
+	p, _ := owner.Properties.Get("pet")
+	assert.Equal(t, "https://example.com/schemas/jsonschema-pet", p.Ref)
+	p, _ = owner.Properties.Get("other_pet")
+	assert.Equal(t, "https://example.com/schemas/nested-pet", p.Ref)
+	assert.Contains(t, schemas, ID("https://example.com/schemas/nested-pet"))
+
+	// original left untouched
+	p, _ = s.Definitions["PetOwner"].Properties.Get("pet")
+	assert.Equal(t, "#/$defs/JsonschemaPet", p.Ref)
+	assert.Empty(t, s.Definitions["PetOwner"].ID)
+}
+
+func TestSplitDefinitionsRecursive(t *testing.T) {
+	r := &Reflector{}
+	s := r.Reflect(&RecursiveExample{})
+
+	schemas, err := SplitDefinitions(s, "https://example.com/schemas")
+	require.NoError(t, err)
+	require.Len(t, schemas, 1)
+	re := schemas["https://example.com/schemas/recursive-example"]
+	require.NotNil(t, re)
+	p, _ := re.Properties.Get("children")
+	assert.Equal(t, "https://example.com/schemas/recursive-example", p.Items.Ref)
+}
+
+func TestSplitDefinitionsExpandedRoot(t *testing.T) {
+	r := &Reflector{ExpandedStruct: true, BaseSchemaID: "https://example.com/schemas"}
+	s := r.Reflect(&OuterNamed{})
+	s.Definitions["Inner"].Properties.Set("self", &Schema{Ref: "#/$defs/Inner/properties/Foo"})
+
+	schemas, err := SplitDefinitions(s, "https://example.com/defs")
+	require.NoError(t, err)
+	require.Len(t, schemas, 2)
+	root := schemas["https://example.com/schemas/outer-named"]
+	require.NotNil(t, root)
+	p, _ := root.Properties.Get("inner")
+	assert.Equal(t, "https://example.com/defs/inner", p.Ref)
+	p, _ = schemas["https://example.com/defs/inner"].Properties.Get("self")
+	assert.Equal(t, "https://example.com/defs/inner#/properties/Foo", p.Ref)
+
+	s.ID = EmptyID
+	_, err = SplitDefinitions(s, "https://example.com/defs")
+	assert.EqualError(t, err, "root schema requires an ID")
+}
+
+func TestSplitDefinitionsErrors(t *testing.T) {
+	s := &Schema{
+		Ref: "#/$defs/Foo",
+		Definitions: Definitions{
+			"Foo": {Type: "object", Items: &Schema{Ref: "#/$defs/Bar"}},
+		},
+	}
+	_, err := SplitDefinitions(s, EmptyID)
+	assert.EqualError(t, err, "base schema ID is required")
+	_, err = SplitDefinitions(s, "https://example.com/schemas")
+	assert.EqualError(t, err, `definition "Foo": unknown reference "#/$defs/Bar" at "/items"`)
+
+	s.Definitions["Bar"] = TrueSchema
+	s.Definitions["bar"] = FalseSchema
+	_, err = SplitDefinitions(s, "https://example.com/schemas")
+	assert.EqualError(t, err, `definitions "Bar" and "bar" share the same ID "https://example.com/schemas/bar"`)
+}
+
+func TestWriteSplitDefinitions(t *testing.T) {
+	r := &Reflector{}
+	s := r.Reflect(&PetOwner{})
+	dir := t.TempDir()
+	require.NoError(t, WriteSplitDefinitions(dir, s, "https://example.com/schemas"))
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	assert.ElementsMatch(t, []string{"pet-owner.json", "jsonschema-pet.json", "nested-pet.json"}, names)
+
+	data, err := os.ReadFile(filepath.Join(dir, "pet-owner.json"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"$ref": "https://example.com/schemas/nested-pet"`)
+	assert.Contains(t, string(data), `"$id": "https://example.com/schemas/pet-owner"`)
+}
+
+func TestWriteSplitDefinitionsFileConflict(t *testing.T) {
+	s := &Schema{
+		Ref: "#/$defs/A",
+		Definitions: Definitions{
+			"A": {ID: "https://example.com/v1/item", Type: "string"},
+			"B": {ID: "https://example.com/v2/item", Type: "integer"},
+		},
+	}
+	dir := t.TempDir()
+	err := WriteSplitDefinitions(dir, s, "https://example.com/schemas")
+	assert.EqualError(t, err, `IDs "https://example.com/v1/item" and "https://example.com/v2/item" share the same file name "item.json"`)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
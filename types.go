@@ -0,0 +1,114 @@
+package jsonschema
+
+import (
+	"reflect"
+	"sync"
+)
+
+// typeList provides the types allowed by the schema, whether defined with
+// Type or Types, or nil if any type is allowed.
+func (t *Schema) typeList() []string {
+	if len(t.Types) > 0 {
+		return t.Types
+	}
+	if t.Type != "" {
+		return []string{t.Type}
+	}
+	return nil
+}
+
+// setTypes defines the types allowed by the schema, using Type when there is
+// only one.
+func (t *Schema) setTypes(types []string) {
+	t.Type = ""
+	t.Types = nil
+	switch len(types) {
+	case 0:
+	case 1:
+		t.Type = types[0]
+	default:
+		t.Types = types
+	}
+}
+
+// typeValue provides the types allowed by the schema as they would be output
+// for the `type` keyword: a single string, or a list.
+func (t *Schema) typeValue() any {
+	if len(t.Types) > 0 {
+		return t.Types
+	}
+	return t.Type
+}
+
+// allowsType determines if values of the type provided are allowed by the
+// schema's types. Integers are allowed by the "number" type.
+func (t *Schema) allowsType(name string) bool {
+	types := t.typeList()
+	if len(types) == 0 {
+		return true
+	}
+	for _, ty := range types {
+		if ty == name || (name == "integer" && ty == "number") {
+			return true
+		}
+	}
+	return false
+}
+
+// keywordType provides the type used to determine which keywords can be set
+// from tags: the schema's only type, ignoring "null", or an empty string if
+// there is more than one.
+func (t *Schema) keywordType() string {
+	if len(t.Types) == 0 {
+		return t.Type
+	}
+	var kt string
+	for _, ty := range t.Types {
+		if ty == "null" {
+			continue
+		}
+		if kt != "" {
+			return ""
+		}
+		kt = ty
+	}
+	return kt
+}
+
+// typeListSchema mirrors the schema's exported fields, with a list in place
+// of the single type, so that a list of types is output in the same position
+// as a single type would be. The indexes of the schema fields copied into each
+// of its fields are included.
+var typeListSchema = sync.OnceValues(func() (reflect.Type, []int) {
+	st := reflect.TypeOf(Schema{})
+	fields := make([]reflect.StructField, 0, st.NumField())
+	indexes := make([]int, 0, st.NumField())
+	for i := 0; i < st.NumField(); i++ {
+		f := st.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		if f.Name == "Type" {
+			f.Type = reflect.TypeOf([]string(nil))
+		}
+		fields = append(fields, f)
+		indexes = append(indexes, i)
+	}
+	return reflect.StructOf(fields), indexes
+})
+
+// withTypeList provides a copy of the schema's fields that will output the
+// list of types.
+func (t *Schema) withTypeList() any {
+	lt, indexes := typeListSchema()
+	v := reflect.New(lt).Elem()
+	src := reflect.ValueOf(t).Elem()
+	for i, idx := range indexes {
+		if lt.Field(i).Name == "Type" {
+			v.Field(i).Set(reflect.ValueOf(t.Types))
+			continue
+		}
+		v.Field(i).Set(src.Field(idx))
+	}
+	return v.Addr().Interface()
+}
@@ -0,0 +1,270 @@
+package jsonschema
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// validateKeywords adds the keywords equivalent to the rules of the
+// go-playground/validator tag defined by the ValidateTag option, and reports
+// if the field is required. Rules that cannot be described by a schema, or
+// that are combined with `|`, are ignored.
+func (r *Reflector) validateKeywords(t *Schema, f reflect.StructField) bool {
+	if r.ValidateTag == "" {
+		return false
+	}
+	tag := f.Tag.Get(r.ValidateTag)
+	if tag == "" || tag == "-" {
+		return false
+	}
+	rules := strings.Split(tag, ",")
+	for i := range rules {
+		rules[i] = strings.NewReplacer("0x2C", ",", "0x7C", "|").Replace(rules[i])
+	}
+	required := false
+	for i, rule := range rules {
+		if rule == "required" {
+			required = true
+		}
+		if rule == "dive" {
+			validateRules(t, f.Type, rules[:i])
+			validateDive(t, f.Type, rules[i+1:])
+			return required
+		}
+	}
+	validateRules(t, f.Type, rules)
+	return required
+}
+
+// validateDive applies the rules that follow `dive` to the items of a slice or
+// array, or to the values of a map, and those between `keys` and `endkeys` to
+// the keys of the map.
+func validateDive(t *Schema, gt reflect.Type, rules []string) {
+	gt = derefType(gt)
+	switch gt.Kind() {
+	case reflect.Slice, reflect.Array:
+		if t.Items == nil {
+			return
+		}
+		t.Items = t.Items.withKeywords()
+		validateNested(t.Items, gt.Elem(), rules)
+	case reflect.Map:
+		if len(rules) > 0 && rules[0] == "keys" {
+			for i, rule := range rules {
+				if rule == "endkeys" {
+					names := &Schema{}
+					if t.PropertyNames != nil {
+						names = t.PropertyNames.withKeywords()
+					}
+					validateRules(names, gt.Key(), rules[1:i])
+					t.PropertyNames = names
+					rules = rules[i+1:]
+					break
+				}
+			}
+		}
+		if t.AdditionalProperties == nil {
+			t.AdditionalProperties = &Schema{}
+		}
+		t.AdditionalProperties = t.AdditionalProperties.withKeywords()
+		validateNested(t.AdditionalProperties, gt.Elem(), rules)
+	}
+}
+
+// validateNested applies the rules to the schema of an item, which may dive
+// further into its own items.
+func validateNested(t *Schema, gt reflect.Type, rules []string) {
+	for i, rule := range rules {
+		if rule == "dive" {
+			validateRules(t, gt, rules[:i])
+			validateDive(t, gt, rules[i+1:])
+			return
+		}
+	}
+	validateRules(t, gt, rules)
+}
+
+// validateRules applies the rules, whose meaning depends on the kind of the
+// Go type they are defined for.
+func validateRules(t *Schema, gt reflect.Type, rules []string) {
+	if zero, ok := validateZero(gt); ok {
+		for i, rule := range rules {
+			if rule != "omitempty" {
+				continue
+			}
+			// the other rules are not checked for the zero value, so allow it
+			// as an alternative
+			c := new(Schema)
+			validateRules(c, gt, append(append([]string{}, rules[:i]...), rules[i+1:]...))
+			if reflect.DeepEqual(c, &Schema{}) {
+				return
+			}
+			alt := []*Schema{{Const: zero}, c}
+			if len(t.AnyOf) == 0 {
+				t.AnyOf = alt
+			} else {
+				t.AllOf = append(t.AllOf, &Schema{AnyOf: alt})
+			}
+			return
+		}
+	}
+
+	gt = derefType(gt)
+	for _, rule := range rules {
+		if strings.Contains(rule, "|") {
+			continue
+		}
+		name, val, _ := strings.Cut(rule, "=")
+		switch name {
+		case "min", "max", "len", "gt", "gte", "lt", "lte":
+			validateBound(t, gt, name, val)
+		case "oneof":
+			validateOneOf(t, gt, val)
+		case "email":
+			t.Format = "email"
+		case "url", "uri":
+			t.Format = "uri"
+		case "uuid", "uuid3", "uuid4", "uuid5", "uuid_rfc4122", "uuid3_rfc4122", "uuid4_rfc4122", "uuid5_rfc4122":
+			t.Format = "uuid"
+		case "hostname", "hostname_rfc1123":
+			t.Format = "hostname"
+		case "ipv4":
+			t.Format = "ipv4"
+		case "ipv6":
+			t.Format = "ipv6"
+		case "ip":
+			t.AnyOf = append(t.AnyOf, &Schema{Format: "ipv4"}, &Schema{Format: "ipv6"})
+		}
+	}
+}
+
+// validateBound sets the limits of the value for numbers, of the length for
+// strings, or of the number of items or properties for slices and maps.
+func validateBound(t *Schema, gt reflect.Type, name, val string) {
+	switch gt.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		num, ok := toJSONNumber(val)
+		if !ok {
+			return
+		}
+		switch name {
+		case "min", "gte":
+			t.Minimum = num
+		case "max", "lte":
+			t.Maximum = num
+		case "len":
+			t.Minimum, t.Maximum = num, num
+		case "gt":
+			t.ExclusiveMinimum = num
+		case "lt":
+			t.ExclusiveMaximum = num
+		}
+		return
+	}
+
+	n, err := strconv.ParseUint(val, 10, 64)
+	if err != nil {
+		return
+	}
+	var lower, upper *uint64
+	switch name {
+	case "min", "gte":
+		lower = &n
+	case "max", "lte":
+		upper = &n
+	case "len":
+		lower, upper = &n, &n
+	case "gt":
+		n++
+		lower = &n
+	case "lt":
+		if n == 0 {
+			return
+		}
+		n--
+		upper = &n
+	}
+	set := func(minimum, maximum **uint64) {
+		if lower != nil {
+			*minimum = lower
+		}
+		if upper != nil {
+			*maximum = upper
+		}
+	}
+	switch gt.Kind() {
+	case reflect.String:
+		set(&t.MinLength, &t.MaxLength)
+	case reflect.Slice, reflect.Array:
+		if gt.Elem().Kind() != reflect.Uint8 {
+			set(&t.MinItems, &t.MaxItems)
+		}
+	case reflect.Map:
+		set(&t.MinProperties, &t.MaxProperties)
+	}
+}
+
+// validateOneOf sets the list of values allowed, separated by spaces, where
+// values containing spaces may be quoted with single quotes.
+func validateOneOf(t *Schema, gt reflect.Type, val string) {
+	var values []string
+	for val = strings.TrimSpace(val); val != ""; val = strings.TrimSpace(val) {
+		if strings.HasPrefix(val, "'") {
+			if end := strings.Index(val[1:], "'"); end >= 0 {
+				values = append(values, val[1:end+1])
+				val = val[end+2:]
+				continue
+			}
+		}
+		v, rest, _ := strings.Cut(val, " ")
+		values = append(values, v)
+		val = rest
+	}
+
+	var enum []any
+	for _, v := range values {
+		switch gt.Kind() {
+		case reflect.String:
+			enum = append(enum, v)
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64:
+			num, ok := toJSONNumber(v)
+			if !ok {
+				return
+			}
+			enum = append(enum, num)
+		default:
+			return
+		}
+	}
+	t.Enum = enum
+}
+
+// validateZero provides the JSON value of the type's zero value, which the
+// `omitempty` rule skips validation for. Nil values are not included, as they
+// are encoded as null, which the keywords of other types do not apply to.
+func validateZero(gt reflect.Type) (any, bool) {
+	switch gt.Kind() {
+	case reflect.String:
+		return "", true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return 0, true
+	case reflect.Bool:
+		return false, true
+	default:
+		return nil, false
+	}
+}
+
+func derefType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
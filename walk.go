@@ -0,0 +1,149 @@
+package jsonschema
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// walk will call the provided function for the schema and every sub-schema
+// it contains, depth first, along with the JSON Pointer to their location
+// relative to the schema the walk started from. If the function returns
+// false, the sub-schemas of the current schema will not be visited.
+func (t *Schema) walk(ptr string, fn func(ptr string, s *Schema) bool) {
+	if t == nil || !fn(ptr, t) {
+		return
+	}
+	t.eachSubschema(ptr, func(p string, s *Schema) {
+		s.walk(p, fn)
+	})
+}
+
+// eachSubschema calls the provided function for each of the schema's direct
+// sub-schemas, in a consistent order, with the JSON Pointer to its location.
+func (t *Schema) eachSubschema(ptr string, fn func(ptr string, s *Schema)) {
+	for _, k := range sortedKeys(t.Definitions) {
+		fn(ptr+"/$defs/"+escapePointer(k), t.Definitions[k])
+	}
+	eachSchemaInList(ptr+"/allOf", t.AllOf, fn)
+	eachSchemaInList(ptr+"/anyOf", t.AnyOf, fn)
+	eachSchemaInList(ptr+"/oneOf", t.OneOf, fn)
+	eachSchema(ptr+"/not", t.Not, fn)
+	eachSchema(ptr+"/if", t.If, fn)
+	eachSchema(ptr+"/then", t.Then, fn)
+	eachSchema(ptr+"/else", t.Else, fn)
+	for _, k := range sortedKeys(t.DependentSchemas) {
+		fn(ptr+"/dependentSchemas/"+escapePointer(k), t.DependentSchemas[k])
+	}
+	eachSchemaInList(ptr+"/prefixItems", t.PrefixItems, fn)
+	eachSchema(ptr+"/items", t.Items, fn)
+	eachSchema(ptr+"/contains", t.Contains, fn)
+	if t.Properties != nil {
+		for pair := t.Properties.Oldest(); pair != nil; pair = pair.Next() {
+			eachSchema(ptr+"/properties/"+escapePointer(pair.Key), pair.Value, fn)
+		}
+	}
+	for _, k := range sortedKeys(t.PatternProperties) {
+		fn(ptr+"/patternProperties/"+escapePointer(k), t.PatternProperties[k])
+	}
+	eachSchema(ptr+"/additionalProperties", t.AdditionalProperties, fn)
+	eachSchema(ptr+"/propertyNames", t.PropertyNames, fn)
+	eachSchema(ptr+"/unevaluatedProperties", t.UnevaluatedProperties, fn)
+	eachSchema(ptr+"/contentSchema", t.ContentSchema, fn)
+}
+
+func eachSchema(ptr string, s *Schema, fn func(ptr string, s *Schema)) {
+	if s != nil {
+		fn(ptr, s)
+	}
+}
+
+func eachSchemaInList(ptr string, list []*Schema, fn func(ptr string, s *Schema)) {
+	for i, s := range list {
+		eachSchema(ptr+"/"+strconv.Itoa(i), s, fn)
+	}
+}
+
+// clone provides a copy of the schema and all of its sub-schemas, so that
+// the structure of the copy can be modified without affecting the original.
+// Values that are not schemas are shared.
+func (t *Schema) clone() *Schema {
+	if t == nil {
+		return nil
+	}
+	c := *t
+	if t.boolean != nil {
+		b := *t.boolean
+		c.boolean = &b
+	}
+	c.Definitions = cloneSchemaMap(t.Definitions)
+	c.AllOf = cloneSchemaList(t.AllOf)
+	c.AnyOf = cloneSchemaList(t.AnyOf)
+	c.OneOf = cloneSchemaList(t.OneOf)
+	c.Not = t.Not.clone()
+	c.If = t.If.clone()
+	c.Then = t.Then.clone()
+	c.Else = t.Else.clone()
+	c.DependentSchemas = cloneSchemaMap(t.DependentSchemas)
+	c.PrefixItems = cloneSchemaList(t.PrefixItems)
+	c.Items = t.Items.clone()
+	c.Contains = t.Contains.clone()
+	if t.Properties != nil {
+		c.Properties = NewProperties()
+		for pair := t.Properties.Oldest(); pair != nil; pair = pair.Next() {
+			c.Properties.Set(pair.Key, pair.Value.clone())
+		}
+	}
+	c.PatternProperties = cloneSchemaMap(t.PatternProperties)
+	c.AdditionalProperties = t.AdditionalProperties.clone()
+	c.PropertyNames = t.PropertyNames.clone()
+	c.UnevaluatedProperties = t.UnevaluatedProperties.clone()
+	c.ContentSchema = t.ContentSchema.clone()
+	return &c
+}
+
+func cloneSchemaList(list []*Schema) []*Schema {
+	if list == nil {
+		return nil
+	}
+	c := make([]*Schema, len(list))
+	for i, s := range list {
+		c[i] = s.clone()
+	}
+	return c
+}
+
+func cloneSchemaMap[M ~map[string]*Schema](m M) M {
+	if m == nil {
+		return nil
+	}
+	c := make(M, len(m))
+	for k, s := range m {
+		c[k] = s.clone()
+	}
+	return c
+}
+
+func sortedKeys[M ~map[string]V, V any](m M) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// escapePointer escapes a key so that it can be used as a JSON Pointer
+// reference token, as per RFC 6901.
+func escapePointer(key string) string {
+	return pointerEscaper.Replace(key)
+}
+
+var pointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+
+// unescapePointer reverses escapePointer.
+func unescapePointer(token string) string {
+	return pointerUnescaper.Replace(token)
+}
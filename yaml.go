@@ -0,0 +1,158 @@
+//go:build jsonschema_yaml
+
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"go.yaml.in/yaml/v4"
+)
+
+var (
+	_ yaml.Marshaler   = &Schema{}
+	_ yaml.Unmarshaler = &Schema{}
+)
+
+// MarshalYAML provides the schema as a YAML node with exactly the same
+// structure as its JSON output, including the order of properties, any
+// extras, and boolean schemas. YAML support is only included when building
+// with the `jsonschema_yaml` tag, and only go.yaml.in/yaml/v4 is supported,
+// other YAML libraries such as gopkg.in/yaml.v3 will not recognise the node.
+func (t *Schema) MarshalYAML() (any, error) {
+	data, err := json.Marshal(t)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return jsonToYAMLNode(dec)
+}
+
+// UnmarshalYAML parses a YAML document as if it were the equivalent JSON,
+// so that the order of properties and boolean schemas are preserved.
+func (t *Schema) UnmarshalYAML(node *yaml.Node) error {
+	buf := new(bytes.Buffer)
+	if err := yamlNodeToJSON(buf, node); err != nil {
+		return err
+	}
+	return json.Unmarshal(buf.Bytes(), t)
+}
+
+// jsonToYAMLNode reads the next JSON value from the decoder and converts it
+// into a YAML node, keeping the order of object keys.
+func jsonToYAMLNode(dec *json.Decoder) (*yaml.Node, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch v := tok.(type) {
+	case json.Delim:
+		n := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		if v == '{' {
+			n = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		}
+		for dec.More() {
+			if n.Kind == yaml.MappingNode {
+				key, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				n.Content = append(n.Content, yamlScalar("!!str", key.(string)))
+			}
+			item, err := jsonToYAMLNode(dec)
+			if err != nil {
+				return nil, err
+			}
+			n.Content = append(n.Content, item)
+		}
+		if _, err := dec.Token(); err != nil { // closing delimiter
+			return nil, err
+		}
+		return n, nil
+	case string:
+		return yamlScalar("!!str", v), nil
+	case json.Number:
+		if strings.ContainsAny(v.String(), ".eE") {
+			return yamlScalar("!!float", v.String()), nil
+		}
+		return yamlScalar("!!int", v.String()), nil
+	case bool:
+		return yamlScalar("!!bool", fmt.Sprint(v)), nil
+	default:
+		return yamlScalar("!!null", "null"), nil
+	}
+}
+
+func yamlScalar(tag, value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: value}
+}
+
+// yamlNodeToJSON writes the YAML node as JSON, keeping the order of mapping
+// keys.
+func yamlNodeToJSON(buf *bytes.Buffer, node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			buf.WriteString("null")
+			return nil
+		}
+		return yamlNodeToJSON(buf, node.Content[0])
+	case yaml.AliasNode:
+		return yamlNodeToJSON(buf, node.Alias)
+	case yaml.MappingNode:
+		buf.WriteByte('{')
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			var key string
+			if err := node.Content[i].Decode(&key); err != nil {
+				return fmt.Errorf("line %d: %w", node.Content[i].Line, err)
+			}
+			k, _ := json.Marshal(key) //nolint:errchkjson
+			buf.Write(k)
+			buf.WriteByte(':')
+			if err := yamlNodeToJSON(buf, node.Content[i+1]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+		return nil
+	case yaml.SequenceNode:
+		buf.WriteByte('[')
+		for i, item := range node.Content {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := yamlNodeToJSON(buf, item); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+		return nil
+	default:
+		if tag := node.ShortTag(); (tag == "!!int" || tag == "!!float") && isJSONNumber(node.Value) {
+			// keep the number exactly as written
+			buf.WriteString(node.Value)
+			return nil
+		}
+		var v any
+		if err := node.Decode(&v); err != nil {
+			return fmt.Errorf("line %d: %w", node.Line, err)
+		}
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", node.Line, err)
+		}
+		buf.Write(data)
+		return nil
+	}
+}
+
+func isJSONNumber(s string) bool {
+	var n json.Number
+	return json.Unmarshal([]byte(s), &n) == nil && n.String() == s
+}
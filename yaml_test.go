@@ -0,0 +1,91 @@
+//go:build jsonschema_yaml
+
+package jsonschema
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.yaml.in/yaml/v4"
+)
+
+func TestSchemaMarshalYAML(t *testing.T) {
+	s := &Schema{
+		Type:       "object",
+		Properties: NewProperties(),
+		Required:   []string{"zeta"},
+		Extras:     map[string]any{"x-order": 1},
+	}
+	s.Properties.Set("zeta", &Schema{Type: "string", Enum: []any{"true", "1"}})
+	s.Properties.Set("alpha", &Schema{Type: "number", Minimum: "1.5", Maximum: "10"})
+	s.Properties.Set("any", TrueSchema)
+	s.AdditionalProperties = FalseSchema
+
+	data, err := yaml.Marshal(s)
+	require.NoError(t, err)
+	assert.Equal(t, `properties:
+    zeta:
+        type: string
+        enum:
+            - "true"
+            - "1"
+    alpha:
+        type: number
+        maximum: 10
+        minimum: 1.5
+    any: true
+additionalProperties: false
+type: object
+required:
+    - zeta
+x-order: 1
+`, string(data))
+
+	s2 := new(Schema)
+	require.NoError(t, yaml.Unmarshal(data, s2))
+	assert.Equal(t, "alpha", s2.Properties.Oldest().Next().Key)
+	s.Extras = nil // as with JSON, extras are not parsed
+	assert.True(t, s2.Equal(s))
+	assert.True(t, isFalseSchema(s2.AdditionalProperties))
+	assert.Equal(t, []any{"true", "1"}, s2.Properties.Oldest().Value.Enum)
+}
+
+func TestSchemaYAMLRoundTrip(t *testing.T) {
+	r := &Reflector{}
+	s := r.Reflect(&TestUser{})
+	data, err := json.Marshal(s)
+	require.NoError(t, err)
+	s1 := new(Schema)
+	require.NoError(t, json.Unmarshal(data, s1))
+	expected, err := json.Marshal(s1)
+	require.NoError(t, err)
+
+	data, err = yaml.Marshal(s)
+	require.NoError(t, err)
+	s2 := new(Schema)
+	require.NoError(t, yaml.Unmarshal(data, s2))
+	actual, err := json.Marshal(s2)
+	require.NoError(t, err)
+	assert.Equal(t, string(expected), string(actual), "should match the JSON round trip")
+}
+
+func TestSchemaUnmarshalYAML(t *testing.T) {
+	s := new(Schema)
+	require.NoError(t, yaml.Unmarshal([]byte(`
+base: &base
+  type: string
+properties:
+  b: *base
+  a: false
+  1: {type: integer}
+`), s))
+	assert.Equal(t, "string", s.Properties.Oldest().Value.Type)
+	assert.Equal(t, "a", s.Properties.Oldest().Next().Key)
+	assert.True(t, isFalseSchema(s.Properties.Oldest().Next().Value))
+	assert.Equal(t, "1", s.Properties.Newest().Key)
+
+	assert.Error(t, yaml.Unmarshal([]byte(`type: [string`), s))
+	assert.Error(t, yaml.Unmarshal([]byte(`minimum: .inf`), s))
+}